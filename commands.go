@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"go-api/player"
+	"go-api/selfplay"
+	"go-api/train"
+	"go-api/tune"
+)
+
+// go-api selfplay [flags]: generate a dataset by playing the engines against each other
+func runSelfPlay(args []string) {
+	opts := selfplay.DefaultOptions
+	flags := flag.NewFlagSet("selfplay", flag.ExitOnError)
+	flags.IntVar(&opts.Games, "games", opts.Games, "number of games to play")
+	flags.IntVar(&opts.Size, "size", opts.Size, "board size")
+	flags.Float64Var(&opts.Komi, "komi", opts.Komi, "komi given to white")
+	flags.Int64Var(&opts.Seed, "seed", opts.Seed, "seed for the engines' random choices")
+	flags.StringVar(&opts.Black, "black", opts.Black, "engine playing black: minimax, mcts or random")
+	flags.StringVar(&opts.White, "white", opts.White, "engine playing white: minimax, mcts or random")
+	flags.IntVar(&opts.Playouts, "playouts", opts.Playouts, "playouts per move for the mcts engine")
+	flags.BoolVar(&opts.Alternate, "alternate", opts.Alternate, "swap colors every other game")
+	out := flags.String("out", "selfplay.jsonl.gz", "dataset file (gzipped if the name ends in .gz)")
+	verbose := flags.Bool("v", false, "log the engines' search details")
+	flags.Parse(args)
+
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+	if err := selfplay.RunToFile(opts, *out, os.Stderr); err != nil {
+		log.SetOutput(os.Stderr)
+		log.Fatal(err)
+	}
+}
+
+// go-api tune [flags]: tune the static evaluator's weights by self-play
+func runTune(args []string) {
+	opts := tune.DefaultOptions
+	flags := flag.NewFlagSet("tune", flag.ExitOnError)
+	flags.IntVar(&opts.Iterations, "iterations", opts.Iterations, "number of tuning iterations")
+	flags.IntVar(&opts.Games, "games", opts.Games, "games played per iteration")
+	flags.IntVar(&opts.Size, "size", opts.Size, "board size")
+	flags.Float64Var(&opts.Komi, "komi", opts.Komi, "komi given to white")
+	flags.Int64Var(&opts.Seed, "seed", opts.Seed, "seed for the perturbations and the engines' random choices")
+	flags.IntVar(&opts.Complexity, "complexity", opts.Complexity, "search complexity during tuning games")
+	flags.Float64Var(&opts.Step, "step", opts.Step, "size of the first step taken")
+	flags.Float64Var(&opts.Perturb, "perturb", opts.Perturb, "size of the first perturbation")
+	flags.IntVar(&opts.Matches, "matches", opts.Matches, "games played against the starting config at the end")
+	from := flags.String("from", "medium", "preset to start from (presets may be added with -presets)")
+	presets := flags.String("presets", "", "file of named evaluator configs to load")
+	out := flags.String("out", "tuned.json", "file to write the tuned config to")
+	name := flags.String("name", "tuned", "name of the tuned config in the output file")
+	verbose := flags.Bool("v", false, "log the engines' search details")
+	flags.Parse(args)
+
+	if *presets != "" {
+		if err := player.LoadPresets(*presets); err != nil {
+			log.Fatal(err)
+		}
+	}
+	start, ok := player.Presets[*from]
+	if !ok {
+		log.Fatalf("unknown preset %q (presets: %s)", *from, strings.Join(player.PresetNames(), ", "))
+	}
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+	if err := tune.RunToFile(start, opts, *out, *name, os.Stderr); err != nil {
+		log.SetOutput(os.Stderr)
+		log.Fatal(err)
+	}
+}
+
+// go-api train [flags]: train a network on a self-play dataset, for NETWORK_WEIGHTS
+func runTrain(args []string) {
+	opts := train.DefaultOptions
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	flags.IntVar(&opts.Size, "size", opts.Size, "board size (positions on other sizes are skipped)")
+	flags.IntVar(&opts.Epochs, "epochs", opts.Epochs, "passes over the dataset")
+	flags.Float64Var(&opts.Rate, "rate", opts.Rate, "learning rate")
+	flags.StringVar(&opts.From, "from", "", "weights file to carry on training from (default a new network)")
+	hidden := flags.String("hidden", "64", "comma separated sizes of a new network's hidden layers")
+	data := flags.String("data", "selfplay.jsonl.gz", "dataset written by selfplay")
+	out := flags.String("out", "network.json", "file to write the weights to")
+	flags.Parse(args)
+
+	opts.Hidden = nil
+	for _, s := range strings.Split(*hidden, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 {
+			log.Fatalf("invalid hidden layer size %q", s)
+		}
+		opts.Hidden = append(opts.Hidden, size)
+	}
+	if err := train.RunToFile(*data, opts, *out, os.Stderr); err != nil {
+		log.Fatal(err)
+	}
+}
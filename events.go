@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"go-api/game"
+)
+
+// kinds of event pushed to clients watching a game
+const (
+	eventState    = "state" // sent once when a client connects
+	eventMove     = "move"
+	eventPass     = "pass"
+	eventResign   = "resign"
+	eventGameOver = "game_over"
+	eventUndo     = "undo"
+	eventRedo     = "redo"
+	eventDead     = "dead"   // a group was marked dead or alive while counting
+	eventAccept   = "accept" // a player accepted the count
+	eventResume   = "resume" // counting was abandoned to carry on playing
+	eventNewGame  = "new_game"
+	eventError    = "error" // a message from this client could not be handled
+)
+
+type gameEvent struct {
+	Type     string          `json:"type"`
+	Move     *game.Move      `json:"move,omitempty"`     // the move that caused the event
+	Captured []game.Point    `json:"captured,omitempty"` // stones removed by the move
+	Board    [][]simplePoint `json:"board,omitempty"`
+	Captures map[string]int  `json:"captures,omitempty"`
+	Score    game.ScoreCount `json:"score"`
+	Ko       [2]int          `json:"ko"`
+	Turn     string          `json:"turn"`
+	Passed   bool            `json:"passed"`
+	Counting bool            `json:"counting"`
+	Dead     [][2]int        `json:"dead,omitempty"`
+	Accepted []string        `json:"accepted,omitempty"`
+	Ended    bool            `json:"ended"`
+	Winner   string          `json:"winner,omitempty"`
+	Result   string          `json:"result,omitempty"`
+	Message  string          `json:"message,omitempty"`
+}
+
+// events are encoded by each subscriber's writer without the session's lock,
+// so they mustn't share any maps or slices with the game (the caller must hold the lock)
+func newEvent(kind string, g game.Game) gameEvent {
+	e := gameEvent{
+		Type:     kind,
+		Captures: copyCounts(g.Captures),
+		Score:    g.Count(),
+		Ko:       g.Ko,
+		Turn:     g.Turn,
+		Passed:   g.Passed,
+		Counting: g.Counting,
+		Dead:     append([][2]int(nil), g.Dead...),
+		Accepted: append([]string(nil), g.Accepted...),
+		Ended:    g.Ended,
+		Winner:   g.Winner,
+		Result:   g.Result,
+	}
+	switch kind {
+	case eventMove, eventPass, eventResign:
+		m := g.Moves[len(g.Moves)-1]
+		e.Move = &m
+		if kind == eventMove {
+			e.Captured = g.LastCaptured()
+		}
+	default:
+		// the whole board may have changed
+		e.Board = simplifyBoard(g.Board)
+	}
+	return e
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	c := make(map[string]int, len(counts))
+	for k, v := range counts {
+		c[k] = v
+	}
+	return c
+}
+
+// a client watching a game over a websocket
+type subscriber struct {
+	events chan gameEvent
+}
+
+// send an event to every subscriber (the caller must hold the session's lock)
+func (s *session) publish(e gameEvent) {
+	for sub := range s.subscribers {
+		select {
+		case sub.events <- e:
+		default:
+			// the client isn't keeping up; drop it rather than block the game
+			s.unsubscribe(sub)
+		}
+	}
+}
+
+func (s *session) subscribe() *subscriber {
+	sub := &subscriber{events: make(chan gameEvent, 32)}
+	s.subscribers[sub] = true
+	return sub
+}
+
+func (s *session) unsubscribe(sub *subscriber) {
+	if s.subscribers[sub] {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// a message sent by the client: a move, a pass or a resignation,
+// or while the game is being counted, marking a dead group, accepting the count or resuming play
+type clientMessage struct {
+	Type  string `json:"type"` // "move", "pass", "resign", "dead", "accept" or "resume"
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Color string `json:"color"` // defaults to the player whose turn it is
+}
+
+// handle a client message, reporting any problem back to that client only
+func (s *session) handleMessage(sub *subscriber, msg clientMessage) {
+	reject := func(message string) {
+		e := newEvent(eventError, s.game)
+		e.Board = nil
+		e.Message = message
+		select {
+		case sub.events <- e:
+		default:
+		}
+	}
+	if s.game.Ended {
+		reject("game has ended")
+		return
+	}
+	switch msg.Type {
+	case eventMove, eventPass, eventResign:
+		if s.game.Counting {
+			reject("game is being counted")
+			return
+		}
+		if s.game.Ended {
+			reject("game has ended")
+			return
+		}
+	case eventDead, eventAccept, eventResume:
+		if !s.game.Counting {
+			reject("game is not being counted")
+			return
+		}
+	}
+	switch msg.Type {
+	case eventMove:
+		if msg.Color == "" {
+			msg.Color = s.game.Turn
+		}
+		if !s.play(game.Point{X: msg.X, Y: msg.Y, Color: msg.Color}) {
+			reject("move data invalid")
+		}
+	case eventPass:
+		s.pass()
+	case eventResign:
+		s.resign()
+	case eventDead:
+		if !s.toggleDead(msg.X, msg.Y) {
+			reject("no stone there")
+		}
+	case eventAccept:
+		if !s.accept(msg.Color) {
+			reject("color invalid or count already accepted")
+		}
+	case eventResume:
+		s.resume()
+	case "invalid":
+		reject("invalid JSON data")
+	default:
+		reject("unknown message type")
+	}
+}
+
+var upgrader = websocket.Upgrader{
+	// clients are served from anywhere, like the rest of the API (see the CORS config)
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// push game events to the client as they happen and accept moves from it
+func getGameSocket(c *gin.Context) {
+	s, ok := findSession(c)
+	if !ok {
+		return
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("websocket upgrade: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	sub := s.subscribe()
+	sub.events <- newEvent(eventState, s.game)
+	s.mu.Unlock()
+
+	// the writer exits once the subscription is closed
+	go func() {
+		for e := range sub.events {
+			if err := conn.WriteJSON(e); err != nil {
+				break
+			}
+		}
+		conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var msg clientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			msg.Type = "invalid"
+		}
+		s.mu.Lock()
+		if s.subscribers[sub] {
+			s.handleMessage(sub, msg)
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	s.unsubscribe(sub)
+	s.mu.Unlock()
+}
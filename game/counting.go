@@ -0,0 +1,107 @@
+package game
+
+// once both players pass in a row the game is counted: players mark the groups
+// they agree are dead, then either both accept the count, which ends the game,
+// or one of them resumes play to settle the disagreement on the board
+
+// no more moves can be played, because the game has ended or is being counted
+func (g Game) Over() bool {
+	return g.Ended || g.Counting
+}
+
+// whether the stone at (x, y) has been marked dead
+func (g Game) IsDead(x, y int) bool {
+	for _, d := range g.Dead {
+		if d == [2]int{x, y} {
+			return true
+		}
+	}
+	return false
+}
+
+// mark the group with a stone at (x, y) dead, or alive again if it was marked dead
+// any acceptance of the count is withdrawn, since the count has changed
+func (g *Game) ToggleDead(x, y int) bool {
+	if !g.Counting || x < 0 || x >= g.Board.Size() || y < 0 || y >= g.Board.Size() {
+		return false
+	}
+	p := g.Board.At(x, y)
+	grp, ok := g.Board.Groups[p.GroupId]
+	if p.Color == "" || !ok {
+		return false
+	}
+	if g.IsDead(x, y) {
+		dead := [][2]int{}
+		for _, d := range g.Dead {
+			if g.Board.At(d[0], d[1]).GroupId != grp.ID {
+				dead = append(dead, d)
+			}
+		}
+		g.Dead = dead
+	} else {
+		for _, gp := range grp.Points {
+			g.Dead = append(g.Dead, [2]int{gp.X, gp.Y})
+		}
+	}
+	g.Accepted = nil
+	return true
+}
+
+// accept the count for color, ending the game once both players have
+// reports false if color has already accepted it
+func (g *Game) AcceptCount(color string) bool {
+	if !g.Counting || (color != "black" && color != "white") {
+		return false
+	}
+	for _, c := range g.Accepted {
+		if c == color {
+			return false
+		}
+	}
+	g.Accepted = append(g.Accepted, color)
+	if len(g.Accepted) == 2 {
+		g.FinishCounting()
+	}
+	return true
+}
+
+// end the game, scoring it with the stones currently marked dead
+// (for games between engines, which don't mark any)
+func (g *Game) FinishCounting() {
+	if !g.Counting {
+		return
+	}
+	g.Counting = false
+	g.Ended = true
+	g.Turn = ""
+	g.Winner, g.Result = g.CountResult()
+}
+
+// stop counting and carry on playing, forgetting any stones marked dead
+// the player who didn't pass last plays next
+func (g *Game) ResumePlay() bool {
+	if !g.Counting {
+		return false
+	}
+	g.Counting = false
+	g.Passed = false
+	g.Dead = nil
+	g.Accepted = nil
+	g.Turn = OppositeColor(g.Moves[len(g.Moves)-1].Color)
+	return true
+}
+
+// the game as it would be if the stones marked dead were captured
+func (g Game) withoutDead() Game {
+	g = g.DeepCopy()
+	for _, d := range g.Dead {
+		p := g.Board.At(d[0], d[1])
+		if p.Color == "" {
+			continue
+		}
+		g.Captures[p.Color]++
+		g.Board.removeStone(d[0], d[1])
+	}
+	g.Dead = nil
+	return g
+}
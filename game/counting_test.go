@@ -0,0 +1,161 @@
+package game
+
+import "testing"
+
+func TestResignWhenOver(t *testing.T) {
+	g, err := FromSGF("(;SZ[5]KM[0.5]RU[Chinese];B[cc];W[];B[])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Resign("black") {
+		t.Error("resigned while counting")
+	}
+	g.AcceptCount("black")
+	g.AcceptCount("white")
+	if !g.Ended || g.Result != "B+24.5" {
+		t.Fatalf("got result %q after accepting the count, want B+24.5", g.Result)
+	}
+	moves := len(g.Moves)
+	for _, color := range []string{"", "black", "white"} {
+		if g.Resign(color) {
+			t.Errorf("%q resigned after the game ended", color)
+		}
+	}
+	if g.Result != "B+24.5" || len(g.Moves) != moves {
+		t.Errorf("resigning changed the result to %q with %d moves", g.Result, len(g.Moves))
+	}
+	if err := g.Apply(Move{Color: "white", Resign: true}); err == nil {
+		t.Error("applied a resignation after the game ended")
+	}
+}
+
+// a game read from sgf, which must end with both players passing
+func countingGame(t *testing.T, sgf string) Game {
+	t.Helper()
+	g, err := FromSGF(sgf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Counting {
+		t.Fatalf("%s is not being counted", sgf)
+	}
+	return g
+}
+
+func TestToggleDead(t *testing.T) {
+	// a two stone white group in black's area
+	g := countingGame(t, "(;SZ[5]KM[0.5]RU[Japanese];B[cc];W[aa];B[dc];W[ab];B[];W[])")
+	if !g.ToggleDead(0, 1) {
+		t.Fatal("couldn't mark the white group dead")
+	}
+	if len(g.Dead) != 2 || !g.IsDead(0, 0) || !g.IsDead(0, 1) {
+		t.Errorf("got dead stones %v, want the whole group", g.Dead)
+	}
+	if g.ToggleDead(4, 4) || g.ToggleDead(-1, 0) || g.ToggleDead(5, 0) {
+		t.Error("marked an empty point or one off the board")
+	}
+	if !g.ToggleDead(0, 0) {
+		t.Fatal("couldn't mark the white group alive again")
+	}
+	if len(g.Dead) != 0 {
+		t.Errorf("got dead stones %v after marking the group alive", g.Dead)
+	}
+
+	g.ToggleDead(2, 2)
+	if !g.AcceptCount("black") || len(g.Accepted) != 1 {
+		t.Fatalf("got acceptances %v, want black's", g.Accepted)
+	}
+	g.ToggleDead(2, 2)
+	if len(g.Accepted) != 0 {
+		t.Errorf("got acceptances %v after changing the count, want none", g.Accepted)
+	}
+}
+
+func TestCountWithDeadStones(t *testing.T) {
+	tests := []struct {
+		name   string
+		sgf    string
+		dead   [][2]int
+		result string
+	}{
+		{"area, none dead", "(;SZ[5]KM[0.5]RU[Chinese];B[cc];W[aa];B[];W[])", nil, "W+0.5"},
+		{"area, white dead", "(;SZ[5]KM[0.5]RU[Chinese];B[cc];W[aa];B[];W[])", [][2]int{{0, 0}}, "B+24.5"},
+		{"territory, none dead", "(;SZ[5]KM[0.5]RU[Japanese];B[cc];W[aa];B[];W[])", nil, "W+0.5"},
+		// the dead stone is a prisoner, as well as leaving black the whole board as territory
+		{"territory, white dead", "(;SZ[5]KM[0.5]RU[Japanese];B[cc];W[aa];B[];W[])", [][2]int{{0, 0}}, "B+24.5"},
+		{"territory, black dead", "(;SZ[5]KM[0.5]RU[Japanese];B[cc];W[aa];B[];W[])", [][2]int{{2, 2}}, "W+25.5"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := countingGame(t, test.sgf)
+			for _, d := range test.dead {
+				g.ToggleDead(d[0], d[1])
+			}
+			g.AcceptCount("black")
+			g.AcceptCount("white")
+			if !g.Ended || g.Result != test.result {
+				t.Errorf("got result %q, want %q", g.Result, test.result)
+			}
+			// the dead stones are only captured for the count
+			if g.Captures["black"] != 0 || g.Captures["white"] != 0 {
+				t.Errorf("counting changed the captures to %v", g.Captures)
+			}
+		})
+	}
+}
+
+func TestResumePlay(t *testing.T) {
+	tests := []struct {
+		name string
+		sgf  string
+		turn string
+	}{
+		{"black passed last", "(;SZ[5];B[cc];W[];B[])", "white"},
+		{"white passed last", "(;SZ[5];B[cc];W[dd];B[];W[])", "black"},
+		{"white to play first", "(;SZ[5]PL[W];W[cc];B[];W[])", "black"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := countingGame(t, test.sgf)
+			g.ToggleDead(2, 2)
+			g.AcceptCount("black")
+			if !g.ResumePlay() {
+				t.Fatal("couldn't resume play")
+			}
+			if g.Counting || g.Passed || g.Turn != test.turn {
+				t.Errorf("got counting %v, passed %v and turn %q, want %q to play", g.Counting, g.Passed, g.Turn, test.turn)
+			}
+			if len(g.Dead) != 0 || len(g.Accepted) != 0 {
+				t.Errorf("kept dead stones %v and acceptances %v", g.Dead, g.Accepted)
+			}
+			if g.ResumePlay() {
+				t.Error("resumed play when the game wasn't being counted")
+			}
+		})
+	}
+}
+
+func TestAcceptCount(t *testing.T) {
+	g := countingGame(t, "(;SZ[5]KM[0.5]RU[Chinese];B[cc];W[];B[])")
+	if g.AcceptCount("") || g.AcceptCount("red") {
+		t.Error("accepted the count for an invalid color")
+	}
+	if !g.AcceptCount("white") {
+		t.Fatal("white couldn't accept the count")
+	}
+	if g.AcceptCount("white") {
+		t.Error("white accepted the count twice")
+	}
+	if g.Ended || len(g.Accepted) != 1 {
+		t.Fatalf("got acceptances %v and ended %v after one player accepted", g.Accepted, g.Ended)
+	}
+	if !g.AcceptCount("black") {
+		t.Fatal("black couldn't accept the count")
+	}
+	if !g.Ended || g.Counting || g.Turn != "" || g.Winner != "black" {
+		t.Errorf("got ended %v, counting %v, turn %q and winner %q after both accepted", g.Ended, g.Counting, g.Turn, g.Winner)
+	}
+	if g.AcceptCount("black") {
+		t.Error("accepted the count after the game ended")
+	}
+}
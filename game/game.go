@@ -1,11 +1,11 @@
 package game
 
 import (
+	"encoding/json"
+
 	"github.com/rs/xid"
 )
 
-// TODO: implement user settings (board Size, scoring style, and?)
-// TODO: implement multiple concurrent games, multiple online players, AI single player mode
 func NewGameBoard(Size int) GameBoard {
 	gbPoints := make([][]*Point, Size, Size)
 	for y := 0; y < Size; y++ {
@@ -37,8 +37,10 @@ func (b GameBoard) DeepCopy() GameBoard {
 		bGroupsCopy[id] = &gCopy
 	}
 	return GameBoard{
+		ID:     b.ID,
 		points: bPointsCopy,
 		Groups: bGroupsCopy,
+		Hash:   b.Hash,
 	}
 }
 
@@ -46,6 +48,7 @@ type GameBoard struct {
 	ID     int
 	points [][]*Point
 	Groups map[string]*Group
+	Hash   uint64 // Zobrist hash of the stones on the board
 }
 
 func (b GameBoard) At(x, y int) *Point {
@@ -81,26 +84,37 @@ func (b *GameBoard) addPoint(p Point) {
 	pointGroup := b.Groups[p.GroupId]
 	pointGroup.addPoint(p, *b)
 	// merge any overlapping groups into one
+	// (in a fixed order, so that the merged group's points and bounds are always in the same order)
 	pointsByGroup := map[string][]Point{}
+	groupOrder := []string{}
 	for _, adjPoint := range p.AdjPoints(*b) {
 		if adjPoint.Color == p.Color && adjPoint.GroupId != p.GroupId {
+			if _, seen := pointsByGroup[adjPoint.GroupId]; !seen {
+				groupOrder = append(groupOrder, adjPoint.GroupId)
+			}
 			pointsByGroup[adjPoint.GroupId] = append(pointsByGroup[adjPoint.GroupId], adjPoint)
 		}
 	}
-	for groupId, points := range pointsByGroup {
+	for _, groupId := range groupOrder {
+		points := pointsByGroup[groupId]
 		adjGroup := b.Groups[groupId]
 		points = append(points, p)
 		pointGroup.connectGroup(*adjGroup, *b, points...)
 	}
 	// add point to board
 	*b.At(p.X, p.Y) = p
+	b.toggleHash(p.X, p.Y, p.Color)
 }
 
-func (b *GameBoard) applyPermissions(ko [2]int) {
-	// check for eyes and apply permissions to prevent suicide
+func (b *GameBoard) applyPermissions(ko [2]int, suicide bool) {
+	// check for eyes and apply permissions to prevent suicide (unless the rules allow it)
 	b.ForEachPoint(func(p *Point) {
 		if p.IsAnEye(*b) {
-			p.Permit = p.calculateEyePermissions(*b)
+			if suicide {
+				p.Permit = map[string]bool{"black": true, "white": true}
+			} else {
+				p.Permit = p.calculateEyePermissions(*b)
+			}
 		}
 	})
 	// apply ko rule
@@ -126,6 +140,7 @@ func (b *GameBoard) doCaptures(friendlyColor string) (capturedPoints map[string]
 		capturedPoints := []Point{}
 		for _, g := range captured {
 			for _, p := range g.Points {
+				b.toggleHash(p.X, p.Y, g.Color)
 				b.At(p.X, p.Y).Color = ""
 				b.At(p.X, p.Y).GroupId = ""
 				b.At(p.X, p.Y).Permit = map[string]bool{"black": true, "white": true}
@@ -143,7 +158,8 @@ func (b *GameBoard) doCaptures(friendlyColor string) (capturedPoints map[string]
 		capturedPoints[enemyColor] = removeCapturedGroups(capturedGroups[enemyColor])
 	}
 
-	// capturing friendlies impossible unless suicide is enabled
+	// a move that captured nothing may leave its own group without liberties,
+	// which is only permitted if the rules allow suicide (see Rules.Suicide)
 	capturedGroups[friendlyColor] = captureGroupsByColor(friendlyColor)
 	if len(capturedGroups[friendlyColor]) >= 1 {
 		capturedPoints[friendlyColor] = removeCapturedGroups(capturedGroups[friendlyColor])
@@ -160,6 +176,8 @@ func (b *GameBoard) ForEachPoint(f func(*Point)) {
 	}
 }
 
+// mark each empty point with the color whose territory it is ("both" if no one's),
+// returning each color's stones and territory (see Game.Count for the game's score)
 func (b *GameBoard) Score() map[string]int {
 	score := map[string]int{"black": 0, "white": 0}
 	// count groups of enclosed free points
@@ -365,7 +383,7 @@ func (p Point) DeepCopy() Point {
 }
 
 func (p Point) AdjPoints(board GameBoard) []Point {
-	AdjPoints := []Point{}
+	AdjPoints := make([]Point, 0, 4)
 	// top
 	if p.Y > 0 {
 		AdjPoints = append(AdjPoints, *board.At(p.X, p.Y-1))
@@ -467,25 +485,52 @@ type Game struct {
 	ID       int            `json:"id"`
 	Board    GameBoard      `json:"board"`
 	Captures map[string]int `json:"captures"`
-	Score    map[string]int `json:"score"`
 	Ko       [2]int         `json:"ko"`
-	Turn     string         `json:"turn"`
-	Passed   bool           `json:"passed"`
-	Ended    bool           `json:"ended"`
-	Winner   string         `json:"winner"`
-}
-
-func NewGame(boardSize int) Game {
-	return Game{
-		Board:    NewGameBoard(boardSize),
+	Komi     float64        `json:"komi"`
+	Rules    Rules          `json:"rules"`
+	MoveTime float64        `json:"move_time"`       // seconds per computer move, see Settings
+	Level    string         `json:"level,omitempty"` // difficulty of the computer opponent, see Settings
+	// the computer's evaluator config, if one was chosen for this game
+	// (kept as JSON, since the player package defines it)
+	EvalConfig json.RawMessage `json:"eval_config,omitempty"`
+	Turn       string          `json:"turn"`
+	Passed     bool            `json:"passed"`
+	Ended      bool            `json:"ended"`
+	// both players have passed and the game is being counted (see counting.go)
+	Counting bool     `json:"counting"`
+	Dead     [][2]int `json:"dead,omitempty"`     // stones marked dead while counting
+	Accepted []string `json:"accepted,omitempty"` // players who have accepted the count
+	Winner   string   `json:"winner"`
+	Result   string   `json:"result"` // e.g. "B+3.5", "W+R" or "Draw"
+	Moves    []Move   `json:"moves"`
+	// stones placed on the board before play began (e.g. handicap or problem positions)
+	SetupStones []Move `json:"setup,omitempty"`
+	// position keys (see positionKey) of every position reached, for superko
+	Positions []uint64 `json:"-"`
+	redo      []Move   // moves taken back by Undo, most recent last
+}
+
+func NewGame(s Settings) (Game, error) {
+	s, err := s.Normalize()
+	if err != nil {
+		return Game{}, err
+	}
+	g := Game{
+		Board:    NewGameBoard(s.Size),
 		Captures: map[string]int{"black": 0, "white": 0},
-		Score:    map[string]int{"black": 0, "white": 0},
 		Ko:       [2]int{-1, -1},
+		Komi:     *s.Komi,
+		Rules:    s.rules(),
+		MoveTime: s.MoveTime,
+		Level:    s.Level,
 		Turn:     "black",
 		Passed:   false,
 		Ended:    false,
 		Winner:   "",
+		Result:   "",
 	}
+	g.Positions = []uint64{g.positionKey(g.Board.Hash, g.Turn)}
+	return g, nil
 }
 
 func (g Game) DeepCopy() Game {
@@ -494,25 +539,47 @@ func (g Game) DeepCopy() Game {
 	for k, v := range g.Captures {
 		capturesCopy[k] = v
 	}
-	scoreCopy := make(map[string]int)
-	for k, v := range g.Score {
-		scoreCopy[k] = v
-	}
 	g.Captures = capturesCopy
-	g.Score = scoreCopy
+	g.Positions = append([]uint64{}, g.Positions...)
+	g.Moves = append([]Move{}, g.Moves...)
+	g.SetupStones = append([]Move{}, g.SetupStones...)
+	g.redo = append([]Move{}, g.redo...)
+	g.Dead = append([][2]int(nil), g.Dead...)
+	g.Accepted = append([]string(nil), g.Accepted...)
+	g.EvalConfig = append(json.RawMessage(nil), g.EvalConfig...)
 	return g
 }
 
+// place a stone on an empty point before the first move, without capturing
+// reports false if the point is occupied or play has already begun
+func (g *Game) AddSetupStone(p Point) bool {
+	inRangeXY := p.X < g.Board.Size() && p.X >= 0 && p.Y < g.Board.Size() && p.Y >= 0
+	if len(g.Moves) > 0 || !inRangeXY || g.Board.At(p.X, p.Y).Color != "" {
+		return false
+	}
+	g.Board.addPoint(Point{X: p.X, Y: p.Y, Color: p.Color})
+	g.Board.resetPermissions(g.Ko, g.Rules.Suicide)
+	g.Board.Score()
+	g.SetupStones = append(g.SetupStones, Move{Color: p.Color, X: p.X, Y: p.Y})
+	// the setup position is the starting point for superko
+	g.Positions = []uint64{g.positionKey(g.Board.Hash, g.Turn)}
+	return true
+}
+
 func (g *Game) IsValidMove(p Point) bool {
 	inRangeXY := p.X < g.Board.Size() && p.X >= 0 && p.Y < g.Board.Size() && p.Y >= 0
 	validColor := p.Color == g.Turn
-	if !g.Ended && inRangeXY && validColor {
-		return g.Board.At(p.X, p.Y).Permit[p.Color]
+	if !g.Over() && inRangeXY && validColor {
+		if !g.Board.At(p.X, p.Y).Permit[p.Color] {
+			return false
+		}
+		return g.Rules.Superko == SuperkoNone || !g.repeatsPosition(p)
 	}
 	return false
 }
 
 func (g *Game) PlayWithoutScoring(p Point) {
+	move := Move{Color: p.Color, X: p.X, Y: p.Y, undo: g.snapshot()}
 	board := &g.Board
 	board.addPoint(p)
 	capturedPoints := board.doCaptures(p.Color)
@@ -522,7 +589,8 @@ func (g *Game) PlayWithoutScoring(p Point) {
 	// apply the rule of ko:
 	// A move may not revert the board back to its previous state
 	g.Ko = [2]int{-1, -1}
-	singlePointCaptured := len(capturedPoints["white"])+len(capturedPoints["black"]) == 1
+	// (a suicide captures no enemy stones, so it can't take a ko)
+	singlePointCaptured := len(capturedPoints[OppositeColor(p.Color)]) == 1
 	if singlePointCaptured {
 		newGroup := board.Groups[board.At(p.X, p.Y).GroupId]
 		newPointInDanger := newGroup.Size() == 1 && newGroup.CountLiberties(*board) == 1
@@ -532,34 +600,61 @@ func (g *Game) PlayWithoutScoring(p Point) {
 			g.Ko = [2]int{koPoint.X, koPoint.Y}
 		}
 	}
-	board.applyPermissions(g.Ko)
+	board.applyPermissions(g.Ko, g.Rules.Suicide)
 
 	g.Turn = OppositeColor(p.Color)
 	g.Passed = false
+	g.Positions = append(g.Positions, g.positionKey(board.Hash, g.Turn))
+	move.undo.captured = capturedPoints
+	g.record(move)
 }
 
 func (g *Game) Play(p Point) (score map[string]int) {
 	g.PlayWithoutScoring(p)
-	g.Score = g.Board.Score()
-	return g.Score
+	return g.Board.Score()
 }
 
 func (g *Game) Pass() {
-	if g.Passed {
-		g.Ended = true
+	g.record(Move{Color: g.Turn, X: -1, Y: -1, Pass: true, undo: g.snapshot()})
+	// under AGA rules white must pass last, so both players have played as many stones
+	if g.Passed && (g.Rules.Scoring != ScoringAGA || g.Turn == "white") {
+		g.Counting = true
 		g.Turn = ""
-		if g.Score["black"] > g.Score["white"] {
-			g.Winner = "black"
-		} else if g.Score["white"] > g.Score["black"] {
-			g.Winner = "white"
-		}
 	} else {
 		g.Passed = true
 		g.Turn = OppositeColor(g.Turn)
 	}
+	// a pass changes the situation (the player to move), if not the board
+	if g.Rules.Superko == SuperkoSituational {
+		passer := g.Moves[len(g.Moves)-1].Color
+		g.Positions = append(g.Positions, g.positionKey(g.Board.Hash, OppositeColor(passer)))
+	}
 }
 
-func (g *Game) Resign(color string) {
+// resign for color, reporting false if the game is over (or being counted)
+func (g *Game) Resign(color string) bool {
+	if g.Over() || (color != "black" && color != "white") {
+		return false
+	}
+	g.record(Move{Color: color, X: -1, Y: -1, Resign: true, undo: g.snapshot()})
 	g.Ended = true
+	g.Counting = false
 	g.Winner = OppositeColor(color)
+	g.Result = colorLetter(g.Winner) + "+R"
+	return true
+}
+
+// compare final scores (white receives komi) to decide the winner and margin
+// the result is formatted as in game records, e.g. "B+3.5" or "Draw"
+func (g Game) CountResult() (winner string, result string) {
+	count := g.Count()
+	return count.Winner, count.Result
+}
+
+// abbreviate a color the way game records do ("B" or "W")
+func colorLetter(color string) string {
+	if color == "white" {
+		return "W"
+	}
+	return "B"
 }
@@ -0,0 +1,268 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/rs/xid"
+)
+
+// a Move is a single entry in the game record: a stone played, a pass or a resignation
+type Move struct {
+	Color  string `json:"color"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Pass   bool   `json:"pass,omitempty"`
+	Resign bool   `json:"resign,omitempty"`
+	undo   undoState
+}
+
+// whatever is needed to take a move back without copying the board
+type undoState struct {
+	captured map[string][]Point
+	ko       [2]int
+	turn     string
+	passed   bool
+	ended    bool
+	counting bool
+	winner   string
+	result   string
+}
+
+func (g *Game) snapshot() undoState {
+	return undoState{
+		ko:       g.Ko,
+		turn:     g.Turn,
+		passed:   g.Passed,
+		ended:    g.Ended,
+		counting: g.Counting,
+		winner:   g.Winner,
+		result:   g.Result,
+	}
+}
+
+// append a move to the record (any moves previously undone can no longer be redone)
+func (g *Game) record(m Move) {
+	g.Moves = append(g.Moves, m)
+	g.redo = nil
+}
+
+func (g *Game) CanUndo() bool {
+	return len(g.Moves) > 0
+}
+
+func (g *Game) CanRedo() bool {
+	return len(g.redo) > 0
+}
+
+// stones captured by the last move, if it was a stone played
+func (g Game) LastCaptured() []Point {
+	captured := []Point{}
+	if len(g.Moves) == 0 {
+		return captured
+	}
+	m := g.Moves[len(g.Moves)-1]
+	for _, color := range []string{"black", "white"} {
+		captured = append(captured, m.undo.captured[color]...)
+	}
+	return captured
+}
+
+// take back the last move, restoring the board and game state from before it was played
+func (g *Game) Undo() bool {
+	if !g.CanUndo() {
+		return false
+	}
+	m := g.Moves[len(g.Moves)-1]
+	g.Moves = g.Moves[:len(g.Moves)-1]
+
+	if !m.Pass && !m.Resign {
+		board := &g.Board
+		// put captured stones back first, since a suicide captures the played stone too
+		for color, points := range m.undo.captured {
+			for _, p := range points {
+				board.restoreStone(p.X, p.Y, color)
+			}
+			g.Captures[color] -= len(points)
+		}
+		board.removeStone(m.X, m.Y)
+		g.Positions = g.Positions[:len(g.Positions)-1]
+	} else if m.Pass && g.Rules.Superko == SuperkoSituational {
+		g.Positions = g.Positions[:len(g.Positions)-1]
+	}
+
+	g.Ko = m.undo.ko
+	g.Turn = m.undo.turn
+	g.Passed = m.undo.passed
+	g.Ended = m.undo.ended
+	g.Counting = m.undo.counting
+	if m.Pass {
+		// marks made while counting go with the pass which started it
+		g.Dead, g.Accepted = nil, nil
+	}
+	g.Winner = m.undo.winner
+	g.Result = m.undo.result
+	g.Board.resetPermissions(g.Ko, g.Rules.Suicide)
+	g.Board.Score()
+
+	g.redo = append(g.redo, m)
+	return true
+}
+
+// replay the last move taken back by Undo
+func (g *Game) Redo() bool {
+	if !g.CanRedo() {
+		return false
+	}
+	m := g.redo[len(g.redo)-1]
+	redo := g.redo[:len(g.redo)-1]
+	if err := g.Apply(m); err != nil {
+		return false
+	}
+	g.redo = redo
+	return true
+}
+
+// play a move from a game record
+func (g *Game) Apply(m Move) error {
+	if g.Ended {
+		return fmt.Errorf("game has already ended")
+	}
+	// a record which carries on after both players passed means play was resumed
+	if g.Counting && !m.Resign {
+		g.ResumePlay()
+	}
+	// records may contain consecutive moves by one color (e.g. handicap stones)
+	if !m.Resign {
+		g.Turn = m.Color
+	}
+	switch {
+	case m.Pass:
+		g.Pass()
+	case m.Resign:
+		if !g.Resign(m.Color) {
+			return fmt.Errorf("%s can't resign", m.Color)
+		}
+	default:
+		p := Point{X: m.X, Y: m.Y, Color: m.Color}
+		if !g.IsValidMove(p) {
+			return fmt.Errorf("illegal move %s at (%d, %d)", m.Color, m.X, m.Y)
+		}
+		g.Play(p)
+	}
+	return nil
+}
+
+// the player who was to move before the first move (e.g. white in a position set up for white to play)
+func (g Game) StartingTurn() string {
+	if len(g.Moves) > 0 {
+		return g.Moves[0].undo.turn
+	}
+	return g.Turn
+}
+
+// give the turn to color before play has begun
+func (g *Game) setStartingTurn(color string) {
+	g.Turn = color
+	// the starting position is the first one for superko
+	g.Positions = []uint64{g.positionKey(g.Board.Hash, g.Turn)}
+}
+
+// Replay rebuilds a game from its settings, setup stones, the player to move first
+// ("" for black) and move record
+func Replay(s Settings, setup []Move, turn string, moves []Move) (Game, error) {
+	g, err := NewGame(s)
+	if err != nil {
+		return g, err
+	}
+	for _, m := range setup {
+		if !g.AddSetupStone(Point{X: m.X, Y: m.Y, Color: m.Color}) {
+			return g, fmt.Errorf("setup stone at (%d, %d) is invalid", m.X, m.Y)
+		}
+	}
+	switch turn {
+	case "":
+	case "black", "white":
+		g.setStartingTurn(turn)
+	default:
+		return g, fmt.Errorf("invalid player to move %q", turn)
+	}
+	for i, m := range moves {
+		if err := g.Apply(m); err != nil {
+			return g, fmt.Errorf("move %d: %v", i+1, err)
+		}
+	}
+	return g, nil
+}
+
+// put a previously captured stone back on the board
+func (b *GameBoard) restoreStone(x, y int, color string) {
+	p := b.At(x, y)
+	p.Color = color
+	p.Territory = ""
+	b.toggleHash(x, y, color)
+	b.regroup(x, y)
+}
+
+// take a stone off the board, splitting its group if necessary
+func (b *GameBoard) removeStone(x, y int) {
+	p := b.At(x, y)
+	if p.Color == "" {
+		return
+	}
+	color := p.Color
+	b.toggleHash(x, y, color)
+	delete(b.Groups, p.GroupId)
+	p.Color = ""
+	p.GroupId = ""
+	for _, adjP := range p.AdjPoints(*b) {
+		if adjP.Color == color {
+			b.regroup(adjP.X, adjP.Y)
+		}
+	}
+}
+
+// rebuild the group containing the stone at (x, y) by flood fill,
+// replacing whatever group(s) its stones previously belonged to
+func (b *GameBoard) regroup(x, y int) {
+	start := b.At(x, y)
+	grp := &Group{
+		ID:     xid.New().String(),
+		Color:  start.Color,
+		Bounds: [][2]int{},
+	}
+	bounds := map[[2]int]bool{}
+	visited := map[[2]int]bool{{x, y}: true}
+	stack := []*Point{start}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if p.GroupId != "" {
+			delete(b.Groups, p.GroupId)
+		}
+		p.GroupId = grp.ID
+		pCopy := *p
+		grp.Points = append(grp.Points, &pCopy)
+		for _, adjP := range p.AdjPoints(*b) {
+			coord := [2]int{adjP.X, adjP.Y}
+			if adjP.Color != grp.Color {
+				if !bounds[coord] {
+					bounds[coord] = true
+					grp.Bounds = append(grp.Bounds, coord)
+				}
+			} else if !visited[coord] {
+				visited[coord] = true
+				stack = append(stack, b.At(adjP.X, adjP.Y))
+			}
+		}
+	}
+	b.Groups[grp.ID] = grp
+}
+
+// recalculate every point's play permissions from scratch
+func (b *GameBoard) resetPermissions(ko [2]int, suicide bool) {
+	b.ForEachPoint(func(p *Point) {
+		open := p.Color == ""
+		p.Permit = map[string]bool{"black": open, "white": open}
+	})
+	b.applyPermissions(ko, suicide)
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// everything Undo and Redo must restore
+func gameState(g Game) string {
+	var sb strings.Builder
+	for _, row := range g.Board.Points() {
+		for _, p := range row {
+			switch p.Color {
+			case "black":
+				sb.WriteByte('X')
+			case "white":
+				sb.WriteByte('O')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('/')
+	}
+	fmt.Fprintf(&sb, " turn=%s captures=%v ko=%v passed=%v counting=%v ended=%v result=%s moves=%d positions=%d",
+		g.Turn, g.Captures, g.Ko, g.Passed, g.Counting, g.Ended, g.Result, len(g.Moves), len(g.Positions))
+	return sb.String()
+}
+
+func TestUndoRedo(t *testing.T) {
+	tests := []struct {
+		name string
+		sgf  string
+	}{
+		{"capture", "(;SZ[5];B[ba];W[aa];B[ab];W[cc])"},
+		{"ko", "(;SZ[5];B[ba];W[ca];B[ab];W[db];B[bc];W[cc];B[cb];W[bb];B[ee];W[ae];B[cb])"},
+		{"passes into counting", "(;SZ[5]RU[Chinese];B[cc];W[];B[])"},
+		{"resignation", "(;SZ[5]RE[B+R];B[cc];W[dd])"},
+		{"situational superko", "(;SZ[5]RU[NZ];B[cc];W[];B[dd];W[])"},
+		{"suicide", "(;SZ[5]RU[NZ];B[ba];W[];B[ab];W[aa])"},
+		{"white first", "(;SZ[5]AB[cc]PL[W];W[dd];B[ee])"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := FromSGF(test.sgf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the state after each move, replayed from the start
+			states := []string{}
+			replay, err := Replay(g.Settings(), g.SetupStones, g.StartingTurn(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			states = append(states, gameState(replay))
+			for _, m := range g.Moves {
+				if err := replay.Apply(m); err != nil {
+					t.Fatal(err)
+				}
+				states = append(states, gameState(replay))
+			}
+			if got, want := states[len(states)-1], gameState(g); got != want {
+				t.Fatalf("replayed: got %s, want %s", got, want)
+			}
+
+			for i := len(g.Moves) - 1; i >= 0; i-- {
+				if !g.Undo() {
+					t.Fatalf("couldn't undo move %d", i)
+				}
+				if got := gameState(g); got != states[i] {
+					t.Errorf("after undoing move %d: got %s, want %s", i, got, states[i])
+				}
+			}
+			if g.Undo() {
+				t.Error("undid a move before the first")
+			}
+			for i := 1; i < len(states); i++ {
+				if !g.Redo() {
+					t.Fatalf("couldn't redo move %d", i-1)
+				}
+				if got := gameState(g); got != states[i] {
+					t.Errorf("after redoing move %d: got %s, want %s", i-1, got, states[i])
+				}
+			}
+			if g.Redo() {
+				t.Error("redid a move which was never undone")
+			}
+
+			// a new move means the moves undone can't be redone
+			if len(g.Moves) > 0 && !g.Ended {
+				g.Undo()
+				g.Pass()
+				if g.CanRedo() {
+					t.Error("can redo after playing a new move")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package game
+
+// static analysis of whether groups can live: their eyes, and seki
+// (groups which share liberties so that neither side can approach the other without being captured)
+
+// an empty region big enough that its owner can always make two eyes in it
+const twoEyeSpace = 7
+
+// a connected region of empty points and the stones bordering it
+type region struct {
+	points []*Point
+	colors map[string]bool // colors of the stones around the region
+	groups map[string]bool // IDs of the groups around the region
+}
+
+// the empty regions of the board
+func (b GameBoard) regions() []*region {
+	regions := []*region{}
+	seen := map[*Point]bool{}
+	b.ForEachPoint(func(p *Point) {
+		if p.Color != "" || seen[p] {
+			return
+		}
+		r := &region{colors: map[string]bool{}, groups: map[string]bool{}}
+		stack := []*Point{p}
+		seen[p] = true
+		for len(stack) > 0 {
+			q := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			r.points = append(r.points, q)
+			for _, adjP := range q.AdjPoints(b) {
+				if adjP.Color != "" {
+					r.colors[adjP.Color] = true
+					r.groups[adjP.GroupId] = true
+					continue
+				}
+				next := b.At(adjP.X, adjP.Y)
+				if !seen[next] {
+					seen[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		regions = append(regions, r)
+	})
+	return regions
+}
+
+// an empty region surrounded by the group's color alone, which the group borders
+func (r *region) isEyeOf(grp *Group) bool {
+	return len(r.colors) == 1 && r.colors[grp.Color] && r.groups[grp.ID]
+}
+
+// count the eyes of a group: the empty regions surrounded by its color alone that it borders,
+// with a region large enough to make two eyes in counting as two (so 2 means the group lives)
+func (b GameBoard) Eyes(grp *Group) int {
+	return eyes(b.regions(), grp)
+}
+
+func eyes(regions []*region, grp *Group) int {
+	n := 0
+	for _, r := range regions {
+		if !r.isEyeOf(grp) {
+			continue
+		}
+		if len(r.points) >= twoEyeSpace {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	if n > 2 {
+		n = 2
+	}
+	return n
+}
+
+// the IDs of the groups in seki
+// a group is taken to be in seki when it has fewer than two eyes, at least two liberties, and
+// every liberty outside its eyes is shared with an enemy group in the same state with as many eyes,
+// so whichever side fills a shared liberty puts itself in atari
+// (a liberty next to nothing but a living enemy is an outside liberty, which makes it a capturing race)
+// (a static estimate: it doesn't read out whether either side could make a second eye)
+func (b GameBoard) Seki() map[string]bool {
+	regions := b.regions()
+	eyePoint := map[*Point]string{} // the color whose eye each point is in
+	for _, r := range regions {
+		if len(r.colors) == 1 {
+			for color := range r.colors {
+				for _, p := range r.points {
+					eyePoint[p] = color
+				}
+			}
+		}
+	}
+
+	// groups which could be in seki, and their liberties outside their eyes
+	candidates := map[string][]*Point{}
+	groupEyes := map[string]int{}
+	for id, grp := range b.Groups {
+		groupEyes[id] = eyes(regions, grp)
+		if groupEyes[id] >= 2 || grp.CountLiberties(b) < 2 {
+			continue
+		}
+		liberties := []*Point{}
+		for _, bound := range grp.Bounds {
+			p := b.At(bound[0], bound[1])
+			if p.Color == "" && eyePoint[p] != grp.Color {
+				liberties = append(liberties, p)
+			}
+		}
+		candidates[id] = liberties
+	}
+
+	// the candidates sharing one of the group's liberties, or nil if one of them isn't shared
+	neighbours := func(grp *Group) map[string]bool {
+		found := map[string]bool{}
+		for _, p := range candidates[grp.ID] {
+			shared := false
+			for _, adjP := range p.AdjPoints(b) {
+				if _, ok := candidates[adjP.GroupId]; ok && adjP.Color == OppositeColor(grp.Color) {
+					found[adjP.GroupId] = true
+					shared = true
+				}
+			}
+			if !shared {
+				return nil
+			}
+		}
+		return found
+	}
+	// ruling out one group can leave its neighbours with outside liberties in turn
+	for changed := true; changed; {
+		changed = false
+		for id := range candidates {
+			if len(neighbours(b.Groups[id])) == 0 {
+				delete(candidates, id)
+				changed = true
+			}
+		}
+	}
+
+	seki := map[string]bool{}
+	for id := range candidates {
+		for other := range neighbours(b.Groups[id]) {
+			if groupEyes[other] == groupEyes[id] {
+				seki[id] = true
+				break
+			}
+		}
+	}
+	return seki
+}
+
+// the empty points in the eyes of groups in seki
+// under territory scoring these belong to no one, like the liberties the groups share
+func (b GameBoard) sekiEyes() []*Point {
+	seki := b.Seki()
+	points := []*Point{}
+	for _, r := range b.regions() {
+		if len(r.colors) != 1 {
+			continue
+		}
+		for id := range r.groups {
+			if seki[id] {
+				points = append(points, r.points...)
+				break
+			}
+		}
+	}
+	return points
+}
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// the stones of the groups in seki, as SGF points in order
+func sekiStones(g Game) []string {
+	seki := g.Board.Seki()
+	stones := []string{}
+	g.Board.ForEachPoint(func(p *Point) {
+		if p.Color != "" && seki[p.GroupId] {
+			stones = append(stones, sgfPoint(p.X, p.Y))
+		}
+	})
+	sort.Strings(stones)
+	return stones
+}
+
+func TestSeki(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup string // SGF properties placing the stones
+		size  int
+		seki  []string
+		// territory and total of black and white under each scoring system
+		territory [4]float64
+		area      [4]float64
+	}{
+		{
+			// black's corner group and white's group between it and black's wall
+			// share both their liberties, with no eyes
+			name:      "no eyes",
+			setup:     "AB[aa][ab][ac][bc][da][db][dc][dd][cd][ce][cf][cg]AW[ca][cb][cc][ad][bd][be][af][bf][bg]",
+			size:      7,
+			seki:      []string{"aa", "ab", "ac", "bc", "ca", "cb", "cc"},
+			territory: [4]float64{24, 24, 2, 2},
+			area:      [4]float64{24, 36, 2, 11},
+		},
+		{
+			// black's group at the top left and white's group beside it have an eye each
+			// (aa and ea) and share the liberty at ca
+			name:      "an eye each",
+			setup:     "AB[ba][ab][bb][ga][gb][cc][dc][ec][fc][gc][cd][ce][cf][cg]AW[da][cb][db][eb][fb][fa][ac][bc][bd][ae][be][bf][ag][bg]",
+			size:      7,
+			seki:      []string{"ab", "ba", "bb", "cb", "da", "db", "eb", "fa", "fb"},
+			territory: [4]float64{16, 16, 2, 2},
+			area:      [4]float64{17, 31, 3, 17},
+		},
+		{
+			// black's group on the d file and white's on the f file share the e file,
+			// but each has outside liberties against the other side's living wall,
+			// so whoever fills them first wins the race
+			name:      "capturing race",
+			setup:     "AW[ba][bb][bc][bd][be][bf][bg][bh][bi][fa][fb][fc][fd][fe][ff][fg][fh][fi]AB[ha][hb][hc][hd][he][hf][hg][hh][hi][da][db][dc][dd][de][df][dg][dh][di]",
+			size:      9,
+			seki:      []string{},
+			territory: [4]float64{9, 9, 9, 9},
+			area:      [4]float64{9, 27, 9, 27},
+		},
+	}
+	for _, test := range tests {
+		for _, scoring := range []struct {
+			rules string
+			want  [4]float64
+		}{{"japanese", test.territory}, {"chinese", test.area}} {
+			t.Run(test.name+"/"+scoring.rules, func(t *testing.T) {
+				g, err := FromSGF(fmt.Sprintf("(;SZ[%d]KM[0]RU[%s]%s)", test.size, scoring.rules, test.setup))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := sekiStones(g); fmt.Sprint(got) != fmt.Sprint(test.seki) {
+					t.Errorf("stones in seki: got %v, want %v", got, test.seki)
+				}
+				count := g.Count()
+				got := [4]float64{float64(count.Black.Territory), count.Black.Total, float64(count.White.Territory), count.White.Total}
+				if got != scoring.want {
+					t.Errorf("black territory and total, white territory and total: got %v, want %v", got, scoring.want)
+				}
+			})
+		}
+	}
+}
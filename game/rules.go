@@ -0,0 +1,125 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules describes the rule set a game is played under
+type Rules struct {
+	Name    string  `json:"name"`
+	Komi    float64 `json:"komi"`    // customary komi under these rules
+	Superko string  `json:"superko"` // which repeated positions are forbidden
+	Scoring string  `json:"scoring"` // how the result is counted
+	// whether a player may play a stone which leaves their own group without liberties,
+	// capturing it (the opponent is credited with the stones)
+	Suicide bool `json:"suicide"`
+}
+
+// superko rules (simple ko is always enforced)
+const (
+	SuperkoNone        = "none"
+	SuperkoPositional  = "positional"  // no move may recreate an earlier board position
+	SuperkoSituational = "situational" // ...with the same player to move
+)
+
+// rule sets which can be selected by name when creating a game
+var RuleSets = map[string]Rules{
+	"chinese":      {Name: "chinese", Komi: 7.5, Superko: SuperkoPositional, Scoring: ScoringArea},
+	"japanese":     {Name: "japanese", Komi: 6.5, Superko: SuperkoNone, Scoring: ScoringTerritory},
+	"korean":       {Name: "korean", Komi: 6.5, Superko: SuperkoNone, Scoring: ScoringTerritory},
+	"aga":          {Name: "aga", Komi: 7.5, Superko: SuperkoSituational, Scoring: ScoringAGA},
+	"nz":           {Name: "nz", Komi: 7, Superko: SuperkoSituational, Scoring: ScoringArea, Suicide: true},
+	"tromp-taylor": {Name: "tromp-taylor", Komi: 7.5, Superko: SuperkoPositional, Scoring: ScoringArea, Suicide: true},
+}
+
+const (
+	MinBoardSize = 2
+	MaxBoardSize = 52 // largest board SGF coordinates can describe
+)
+
+// Settings chosen by the players when a game is created
+// fields left unset fall back to DefaultSettings (komi, superko, scoring and suicide fall back to the rule set)
+type Settings struct {
+	Size    int      `json:"size"`
+	Komi    *float64 `json:"komi"`
+	Rules   string   `json:"rules"`
+	Superko string   `json:"superko"`
+	Scoring string   `json:"scoring"`
+	Suicide *bool    `json:"suicide"`
+	// seconds the computer may think about each move (0 uses the server's default)
+	MoveTime float64 `json:"move_time"`
+	// difficulty level of the computer opponent, if any (levels are defined by the server)
+	Level string `json:"level,omitempty"`
+}
+
+var DefaultSettings = Settings{Size: 9, Rules: "chinese"}
+
+// fill in defaults for anything left unset and reject invalid settings
+func (s Settings) Normalize() (Settings, error) {
+	if s.Size == 0 {
+		s.Size = DefaultSettings.Size
+	}
+	if s.Size < MinBoardSize || s.Size > MaxBoardSize {
+		return s, fmt.Errorf("board size must be between %d and %d", MinBoardSize, MaxBoardSize)
+	}
+	if s.Rules == "" {
+		s.Rules = DefaultSettings.Rules
+	}
+	s.Rules = strings.ToLower(s.Rules)
+	rules, ok := RuleSets[s.Rules]
+	if !ok {
+		return s, fmt.Errorf("unknown rule set %q", s.Rules)
+	}
+	switch s.Superko {
+	case "":
+		s.Superko = rules.Superko
+	case SuperkoNone, SuperkoPositional, SuperkoSituational:
+	default:
+		return s, fmt.Errorf("unknown superko rule %q", s.Superko)
+	}
+	switch s.Scoring {
+	case "":
+		s.Scoring = rules.Scoring
+	case ScoringArea, ScoringTerritory, ScoringAGA, ScoringStone:
+	default:
+		return s, fmt.Errorf("unknown scoring system %q", s.Scoring)
+	}
+	if s.Komi == nil {
+		komi := rules.Komi
+		s.Komi = &komi
+	}
+	if s.Suicide == nil {
+		suicide := rules.Suicide
+		s.Suicide = &suicide
+	}
+	if s.MoveTime < 0 {
+		return s, fmt.Errorf("move time must not be negative")
+	}
+	return s, nil
+}
+
+// rules for a game created with the given (normalized) settings
+func (s Settings) rules() Rules {
+	rules := RuleSets[s.Rules]
+	rules.Superko = s.Superko
+	rules.Scoring = s.Scoring
+	rules.Suicide = *s.Suicide
+	return rules
+}
+
+// settings that would recreate this game's board, komi and rules
+func (g Game) Settings() Settings {
+	komi := g.Komi
+	suicide := g.Rules.Suicide
+	return Settings{
+		Size:     g.Board.Size(),
+		Komi:     &komi,
+		Rules:    g.Rules.Name,
+		Superko:  g.Rules.Superko,
+		Scoring:  g.Rules.Scoring,
+		Suicide:  &suicide,
+		MoveTime: g.MoveTime,
+		Level:    g.Level,
+	}
+}
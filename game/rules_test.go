@@ -0,0 +1,76 @@
+package game
+
+import "testing"
+
+func TestSuicide(t *testing.T) {
+	yes, no := true, false
+	positions := []struct {
+		name     string
+		black    [][2]int
+		white    [][2]int
+		move     Point // played by white
+		suicide  bool  // the move takes its own stones off the board
+		repeats  bool  // ...leaving the board as it was
+		captured int   // white stones lost if it's played
+	}{
+		{"one stone", [][2]int{{1, 0}, {0, 1}}, nil, Point{X: 0, Y: 0}, true, true, 1},
+		{"two stones", [][2]int{{1, 0}, {1, 1}, {0, 2}}, [][2]int{{0, 0}}, Point{X: 0, Y: 1}, true, false, 2},
+		{"capturing", [][2]int{{1, 0}, {0, 1}}, [][2]int{{2, 0}, {1, 1}, {0, 2}}, Point{X: 0, Y: 0}, false, false, 0},
+	}
+	rules := []struct {
+		name       string
+		rules      string
+		suicide    *bool
+		allowed    bool
+		positional bool // positional superko forbids a suicide which leaves the board as it was
+	}{
+		{"chinese", "chinese", nil, false, true},
+		{"nz", "nz", nil, true, false},
+		{"tromp-taylor", "tromp-taylor", nil, true, true},
+		{"chinese with suicide", "chinese", &yes, true, true},
+		{"nz without suicide", "nz", &no, false, false},
+	}
+	for _, pos := range positions {
+		for _, r := range rules {
+			t.Run(pos.name+"/"+r.name, func(t *testing.T) {
+				g, err := NewGame(Settings{Size: 5, Rules: r.rules, Suicide: r.suicide})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, p := range pos.black {
+					g.AddSetupStone(Point{X: p[0], Y: p[1], Color: "black"})
+				}
+				for _, p := range pos.white {
+					g.AddSetupStone(Point{X: p[0], Y: p[1], Color: "white"})
+				}
+				g.setStartingTurn("white")
+				move := Point{X: pos.move.X, Y: pos.move.Y, Color: "white"}
+				legal := (!pos.suicide || r.allowed) && !(pos.repeats && r.positional)
+				if got := g.IsValidMove(move); got != legal {
+					t.Fatalf("got legal %v, want %v", got, legal)
+				}
+				if !legal {
+					return
+				}
+				hash := g.Board.hashAfter(move)
+				g.Play(move)
+				if g.Board.Hash != hash {
+					t.Errorf("hash %x after the move, predicted %x", g.Board.Hash, hash)
+				}
+				if got := g.Captures["white"]; got != pos.captured {
+					t.Errorf("got %d white stones captured, want %d", got, pos.captured)
+				}
+				want := "white"
+				if pos.suicide {
+					want = ""
+				}
+				if got := g.Board.At(move.X, move.Y).Color; got != want {
+					t.Errorf("got %q on the point played, want %q", got, want)
+				}
+				if g.Turn != "black" {
+					t.Errorf("got %q to move after the move, want black", g.Turn)
+				}
+			})
+		}
+	}
+}
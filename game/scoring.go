@@ -0,0 +1,99 @@
+package game
+
+import "strconv"
+
+// scoring systems
+const (
+	ScoringArea      = "area"      // stones on the board plus surrounded territory (e.g. Chinese rules)
+	ScoringTerritory = "territory" // surrounded territory plus prisoners (e.g. Japanese and Korean rules)
+	// territory plus prisoners, where every pass hands the opponent a prisoner and
+	// white must pass last, so the result agrees with area scoring (AGA rules)
+	ScoringAGA   = "aga"
+	ScoringStone = "stone" // stones on the board only (ancient Chinese rules)
+)
+
+// a player's score, broken down by where it comes from
+// only the parts counted by the game's scoring system are filled in
+type PlayerScore struct {
+	Territory int     `json:"territory"` // empty points surrounded by the player's stones alone
+	Stones    int     `json:"stones"`    // the player's stones on the board
+	Prisoners int     `json:"prisoners"` // opponent stones captured (and pass stones under AGA rules)
+	Komi      float64 `json:"komi"`
+	Total     float64 `json:"total"`
+}
+
+// ScoreCount is the count of a game under its scoring system, as it stands
+type ScoreCount struct {
+	Scoring string      `json:"scoring"`
+	Black   PlayerScore `json:"black"`
+	White   PlayerScore `json:"white"`
+	Winner  string      `json:"winner"` // "" for a draw
+	Result  string      `json:"result"` // e.g. "B+3.5" or "Draw"
+}
+
+// count the score of the board as it stands, under the game's scoring system
+// stones marked dead are counted as captured
+func (g Game) Count() ScoreCount {
+	if len(g.Dead) > 0 {
+		g = g.withoutDead()
+	}
+	count := ScoreCount{Scoring: g.Rules.Scoring}
+	players := map[string]*PlayerScore{"black": &count.Black, "white": &count.White}
+
+	// scoring marks each empty point with the color whose territory it is
+	g.Board.Score()
+	territory := map[string]int{}
+	stones := map[string]int{}
+	g.Board.ForEachPoint(func(p *Point) {
+		if p.Color == "" {
+			territory[p.Territory]++
+		} else {
+			stones[p.Color]++
+		}
+	})
+	if g.Rules.Scoring == ScoringTerritory {
+		// territory must be surrounded by living stones, and groups in seki only live
+		// because neither side can approach the other, so their eyes aren't territory
+		// (area scoring counts them, as it does the stones themselves)
+		for _, p := range g.Board.sekiEyes() {
+			territory[p.Territory]--
+		}
+	}
+	passes := map[string]int{}
+	for _, m := range g.Moves {
+		if m.Pass {
+			passes[m.Color]++
+		}
+	}
+
+	for color, s := range players {
+		switch g.Rules.Scoring {
+		case ScoringArea:
+			s.Territory, s.Stones = territory[color], stones[color]
+		case ScoringTerritory:
+			s.Territory, s.Prisoners = territory[color], g.Captures[OppositeColor(color)]
+		case ScoringAGA:
+			s.Territory = territory[color]
+			s.Prisoners = g.Captures[OppositeColor(color)] + passes[OppositeColor(color)]
+		case ScoringStone:
+			s.Stones = stones[color]
+		}
+		s.Total = float64(s.Territory + s.Stones + s.Prisoners)
+	}
+	count.White.Komi = g.Komi
+	count.White.Total += g.Komi
+
+	margin := count.Black.Total - count.White.Total
+	switch {
+	case margin > 0:
+		count.Winner = "black"
+	case margin < 0:
+		count.Winner = "white"
+		margin = -margin
+	default:
+		count.Result = "Draw"
+		return count
+	}
+	count.Result = colorLetter(count.Winner) + "+" + strconv.FormatFloat(margin, 'f', -1, 64)
+	return count
+}
@@ -0,0 +1,109 @@
+package game
+
+import "testing"
+
+// black walls off the left of a 5x5 board and captures a white stone played inside,
+// white walls off the right, and the middle column is dame (black has a stone there)
+// white passes once during play, then each player passes
+const scoringRecord = ";B[ba];W[da];B[bb];W[db];B[bc];W[dc];B[bd];W[dd];B[be];W[de]" +
+	";B[cc];W[ac];B[ab];W[];B[ad];W[];B[]"
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    string
+		scoring  string
+		extra    string // moves after the record
+		counting bool
+		black    PlayerScore
+		white    PlayerScore
+		result   string
+	}{
+		{
+			name:     "area",
+			rules:    "chinese",
+			counting: true,
+			black:    PlayerScore{Territory: 3, Stones: 8, Total: 11},
+			white:    PlayerScore{Territory: 5, Stones: 5, Komi: 0.5, Total: 10.5},
+			result:   "B+0.5",
+		},
+		{
+			name:     "territory",
+			rules:    "japanese",
+			counting: true,
+			black:    PlayerScore{Territory: 3, Prisoners: 1, Total: 4},
+			white:    PlayerScore{Territory: 5, Komi: 0.5, Total: 5.5},
+			result:   "W+1.5",
+		},
+		{
+			// black passed last, so white must pass again; until then black has played a stone more
+			name:   "aga before white passes last",
+			rules:  "aga",
+			black:  PlayerScore{Territory: 3, Prisoners: 3, Total: 6},
+			white:  PlayerScore{Territory: 5, Prisoners: 1, Komi: 0.5, Total: 6.5},
+			result: "W+0.5",
+		},
+		{
+			// white's pass stones make up for the stones black played, agreeing with area scoring
+			name:     "aga",
+			rules:    "aga",
+			extra:    ";W[]",
+			counting: true,
+			black:    PlayerScore{Territory: 3, Prisoners: 4, Total: 7},
+			white:    PlayerScore{Territory: 5, Prisoners: 1, Komi: 0.5, Total: 6.5},
+			result:   "B+0.5",
+		},
+		{
+			name:     "stone",
+			rules:    "chinese",
+			scoring:  ScoringStone,
+			counting: true,
+			black:    PlayerScore{Stones: 8, Total: 8},
+			white:    PlayerScore{Stones: 5, Komi: 0.5, Total: 5.5},
+			result:   "B+2.5",
+		},
+		{
+			name:     "territory under chinese rules",
+			rules:    "chinese",
+			scoring:  ScoringTerritory,
+			counting: true,
+			black:    PlayerScore{Territory: 3, Prisoners: 1, Total: 4},
+			white:    PlayerScore{Territory: 5, Komi: 0.5, Total: 5.5},
+			result:   "W+1.5",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record, err := FromSGF("(;SZ[5]" + scoringRecord + test.extra + ")")
+			if err != nil {
+				t.Fatal(err)
+			}
+			komi := 0.5
+			g, err := NewGame(Settings{Size: 5, Komi: &komi, Rules: test.rules, Scoring: test.scoring})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range record.Moves {
+				if err := g.Apply(m); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if g.Counting != test.counting {
+				t.Errorf("counting: got %v, want %v", g.Counting, test.counting)
+			}
+			count := g.Count()
+			if count.Scoring != g.Rules.Scoring {
+				t.Errorf("scoring: got %q, want %q", count.Scoring, g.Rules.Scoring)
+			}
+			if count.Black != test.black {
+				t.Errorf("black: got %+v, want %+v", count.Black, test.black)
+			}
+			if count.White != test.white {
+				t.Errorf("white: got %+v, want %+v", count.White, test.white)
+			}
+			if count.Result != test.result {
+				t.Errorf("result: got %q, want %q", count.Result, test.result)
+			}
+		})
+	}
+}
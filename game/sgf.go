@@ -0,0 +1,365 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// names used for each rule set in the SGF RU property
+var sgfRuleNames = map[string]string{
+	"chinese":      "Chinese",
+	"japanese":     "Japanese",
+	"korean":       "Korean",
+	"aga":          "AGA",
+	"nz":           "NZ",
+	"tromp-taylor": "Tromp-Taylor",
+}
+
+// SGF coordinates are letters: a-z for 0-25 then A-Z for 26-51
+func sgfCoord(n int) byte {
+	if n < 26 {
+		return byte('a' + n)
+	}
+	return byte('A' + n - 26)
+}
+
+func sgfPoint(x, y int) string {
+	return string([]byte{sgfCoord(x), sgfCoord(y)})
+}
+
+// escape characters with special meaning inside an SGF property value
+func sgfEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "]", `\]`)
+}
+
+// ToSGF writes the game record in SGF (FF[4]) format
+func (g Game) ToSGF() string {
+	var sb strings.Builder
+	sb.WriteString("(;FF[4]GM[1]CA[UTF-8]AP[go-api]")
+	fmt.Fprintf(&sb, "SZ[%d]", g.Board.Size())
+	fmt.Fprintf(&sb, "KM[%s]", strconv.FormatFloat(g.Komi, 'f', -1, 64))
+	if name, ok := sgfRuleNames[g.Rules.Name]; ok {
+		fmt.Fprintf(&sb, "RU[%s]", sgfEscape(name))
+	}
+	if g.Result != "" {
+		fmt.Fprintf(&sb, "RE[%s]", sgfEscape(g.Result))
+	}
+	for _, color := range []string{"black", "white"} {
+		var points []string
+		for _, m := range g.SetupStones {
+			if m.Color == color {
+				points = append(points, "["+sgfPoint(m.X, m.Y)+"]")
+			}
+		}
+		if len(points) > 0 {
+			fmt.Fprintf(&sb, "A%s%s", colorLetter(color), strings.Join(points, ""))
+		}
+	}
+	// once moves are recorded they show whose turn it is
+	if len(g.Moves) == 0 && g.Turn != "" {
+		fmt.Fprintf(&sb, "PL[%s]", colorLetter(g.Turn))
+	}
+	for _, m := range g.Moves {
+		switch {
+		case m.Resign:
+			// resignation is recorded in RE, not as a move
+		case m.Pass:
+			fmt.Fprintf(&sb, "\n;%s[]", colorLetter(m.Color))
+		default:
+			fmt.Fprintf(&sb, "\n;%s[%s]", colorLetter(m.Color), sgfPoint(m.X, m.Y))
+		}
+	}
+	sb.WriteString(")\n")
+	return sb.String()
+}
+
+// a node of an SGF game tree: property identifiers and their values
+type sgfNode map[string][]string
+
+type sgfParser struct {
+	data string
+	pos  int
+}
+
+func (sp *sgfParser) skipSpace() {
+	for sp.pos < len(sp.data) && strings.ContainsRune(" \t\r\n", rune(sp.data[sp.pos])) {
+		sp.pos++
+	}
+}
+
+func (sp *sgfParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("sgf: offset %d: %s", sp.pos, fmt.Sprintf(format, args...))
+}
+
+// parse a game tree, following only its first variation (the main line)
+func (sp *sgfParser) parseTree() ([]sgfNode, error) {
+	sp.skipSpace()
+	if sp.pos >= len(sp.data) || sp.data[sp.pos] != '(' {
+		return nil, sp.errorf("expected '('")
+	}
+	sp.pos++
+	nodes := []sgfNode{}
+	for {
+		sp.skipSpace()
+		if sp.pos >= len(sp.data) {
+			return nil, sp.errorf("unexpected end of file")
+		}
+		switch sp.data[sp.pos] {
+		case ';':
+			sp.pos++
+			node, err := sp.parseNode()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		case '(':
+			variation, err := sp.parseTree()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, variation...)
+			// skip any remaining variations
+			for {
+				sp.skipSpace()
+				if sp.pos >= len(sp.data) || sp.data[sp.pos] != '(' {
+					break
+				}
+				if _, err := sp.parseTree(); err != nil {
+					return nil, err
+				}
+			}
+		case ')':
+			sp.pos++
+			if len(nodes) == 0 {
+				return nil, sp.errorf("empty game tree")
+			}
+			return nodes, nil
+		default:
+			return nil, sp.errorf("unexpected %q", sp.data[sp.pos])
+		}
+	}
+}
+
+func (sp *sgfParser) parseNode() (sgfNode, error) {
+	node := sgfNode{}
+	for {
+		sp.skipSpace()
+		start := sp.pos
+		for sp.pos < len(sp.data) && sp.data[sp.pos] >= 'A' && sp.data[sp.pos] <= 'Z' {
+			sp.pos++
+		}
+		if sp.pos == start {
+			return node, nil
+		}
+		ident := sp.data[start:sp.pos]
+		sp.skipSpace()
+		if sp.pos >= len(sp.data) || sp.data[sp.pos] != '[' {
+			return nil, sp.errorf("property %s has no value", ident)
+		}
+		for sp.pos < len(sp.data) && sp.data[sp.pos] == '[' {
+			value, err := sp.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			node[ident] = append(node[ident], value)
+			sp.skipSpace()
+		}
+	}
+}
+
+func (sp *sgfParser) parseValue() (string, error) {
+	sp.pos++ // opening bracket
+	var sb strings.Builder
+	for sp.pos < len(sp.data) {
+		ch := sp.data[sp.pos]
+		sp.pos++
+		switch ch {
+		case '\\':
+			if sp.pos < len(sp.data) {
+				sb.WriteByte(sp.data[sp.pos])
+				sp.pos++
+			}
+		case ']':
+			return sb.String(), nil
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return "", sp.errorf("unterminated property value")
+}
+
+func parseSGFCoord(c byte) (int, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 26, true
+	}
+	return 0, false
+}
+
+// parse a point such as "cd", reporting pass ("" or "tt" on small boards) as (-1, -1)
+func parseSGFPoint(value string, size int) (x, y int, err error) {
+	if value == "" || (value == "tt" && size <= 19) {
+		return -1, -1, nil
+	}
+	if len(value) == 2 {
+		x, okX := parseSGFCoord(value[0])
+		y, okY := parseSGFCoord(value[1])
+		if okX && okY && x < size && y < size {
+			return x, y, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid point %q", value)
+}
+
+// parse a list of points, expanding compressed rectangles such as "aa:cc"
+func parseSGFPointList(values []string, size int) ([][2]int, error) {
+	points := [][2]int{}
+	for _, value := range values {
+		corners := strings.Split(value, ":")
+		if len(corners) > 2 {
+			return nil, fmt.Errorf("invalid point %q", value)
+		}
+		x1, y1, err := parseSGFPoint(corners[0], size)
+		if err != nil || x1 < 0 {
+			return nil, fmt.Errorf("invalid point %q", value)
+		}
+		x2, y2 := x1, y1
+		if len(corners) == 2 {
+			if x2, y2, err = parseSGFPoint(corners[1], size); err != nil || x2 < x1 || y2 < y1 {
+				return nil, fmt.Errorf("invalid point %q", value)
+			}
+		}
+		for y := y1; y <= y2; y++ {
+			for x := x1; x <= x2; x++ {
+				points = append(points, [2]int{x, y})
+			}
+		}
+	}
+	return points, nil
+}
+
+// read the game settings from the root node of an SGF record
+func sgfSettings(root sgfNode) (Settings, error) {
+	s := Settings{Size: 19} // the SGF default when SZ is missing
+	if gm, ok := root["GM"]; ok && gm[0] != "1" {
+		return s, fmt.Errorf("sgf: GM[%s] is not a game of Go", gm[0])
+	}
+	if sz, ok := root["SZ"]; ok {
+		size, err := strconv.Atoi(strings.TrimSpace(sz[0]))
+		if err != nil {
+			return s, fmt.Errorf("sgf: SZ[%s]: only square boards are supported", sz[0])
+		}
+		s.Size = size
+	}
+	if ru, ok := root["RU"]; ok {
+		for name, sgfName := range sgfRuleNames {
+			if strings.EqualFold(strings.TrimSpace(ru[0]), sgfName) {
+				s.Rules = name
+			}
+		}
+	}
+	if km, ok := root["KM"]; ok {
+		komi, err := strconv.ParseFloat(strings.TrimSpace(km[0]), 64)
+		if err != nil {
+			return s, fmt.Errorf("sgf: KM[%s]: invalid komi", km[0])
+		}
+		s.Komi = &komi
+	}
+	return s, nil
+}
+
+// FromSGF loads a game from an SGF record, placing any setup stones (AB/AW)
+// and replaying the main line of moves
+func FromSGF(data string) (Game, error) {
+	sp := &sgfParser{data: data}
+	nodes, err := sp.parseTree()
+	if err != nil {
+		return Game{}, err
+	}
+	settings, err := sgfSettings(nodes[0])
+	if err != nil {
+		return Game{}, err
+	}
+	g, err := NewGame(settings)
+	if err != nil {
+		return Game{}, fmt.Errorf("sgf: %v", err)
+	}
+	size := g.Board.Size()
+
+	for i, node := range nodes {
+		nodeErr := func(format string, args ...interface{}) error {
+			return fmt.Errorf("sgf: node %d: %s", i, fmt.Sprintf(format, args...))
+		}
+		for _, prop := range []string{"AB", "AW"} {
+			values, ok := node[prop]
+			if !ok {
+				continue
+			}
+			if len(g.Moves) > 0 {
+				return Game{}, nodeErr("setup stones after the first move are not supported")
+			}
+			points, err := parseSGFPointList(values, size)
+			if err != nil {
+				return Game{}, nodeErr("%s: %v", prop, err)
+			}
+			color := "black"
+			if prop == "AW" {
+				color = "white"
+			}
+			for _, pt := range points {
+				if !g.AddSetupStone(Point{X: pt[0], Y: pt[1], Color: color}) {
+					return Game{}, nodeErr("%s[%s]: point is already occupied", prop, sgfPoint(pt[0], pt[1]))
+				}
+			}
+		}
+		if pl, ok := node["PL"]; ok {
+			color := ""
+			switch strings.ToUpper(pl[0]) {
+			case "B":
+				color = "black"
+			case "W":
+				color = "white"
+			default:
+				return Game{}, nodeErr("PL[%s]: invalid color", pl[0])
+			}
+			if len(g.Moves) == 0 {
+				g.setStartingTurn(color)
+			} else {
+				g.Turn = color
+			}
+		}
+		for _, prop := range []string{"B", "W"} {
+			values, ok := node[prop]
+			if !ok {
+				continue
+			}
+			x, y, err := parseSGFPoint(values[0], size)
+			if err != nil {
+				return Game{}, nodeErr("%s: %v", prop, err)
+			}
+			color := "black"
+			if prop == "W" {
+				color = "white"
+			}
+			m := Move{Color: color, X: x, Y: y, Pass: x < 0}
+			if err := g.Apply(m); err != nil {
+				return Game{}, nodeErr("%s[%s]: %v", prop, values[0], err)
+			}
+		}
+	}
+
+	// resignations are only recorded in the result
+	if re, ok := nodes[0]["RE"]; ok && !g.Ended {
+		switch strings.ToUpper(strings.TrimSpace(re[0])) {
+		case "B+R", "B+RESIGN":
+			g.Resign("white")
+		case "W+R", "W+RESIGN":
+			g.Resign("black")
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,109 @@
+package game
+
+import "testing"
+
+func TestToSGF(t *testing.T) {
+	komi := 0.5
+	tests := []struct {
+		name     string
+		settings Settings
+		play     func(g *Game)
+		want     string
+	}{
+		{
+			name:     "new game",
+			settings: Settings{Size: 9},
+			play:     func(g *Game) {},
+			want:     "(;FF[4]GM[1]CA[UTF-8]AP[go-api]SZ[9]KM[7.5]RU[Chinese]PL[B])\n",
+		},
+		{
+			name:     "in progress",
+			settings: Settings{Size: 9, Komi: &komi, Rules: "japanese"},
+			play: func(g *Game) {
+				g.Play(Point{X: 2, Y: 2, Color: "black"})
+				g.Pass()
+				g.Play(Point{X: 6, Y: 6, Color: "black"})
+			},
+			want: "(;FF[4]GM[1]CA[UTF-8]AP[go-api]SZ[9]KM[0.5]RU[Japanese]\n;B[cc]\n;W[]\n;B[gg])\n",
+		},
+		{
+			name:     "setup stones",
+			settings: Settings{Size: 5, Rules: "aga"},
+			play: func(g *Game) {
+				g.AddSetupStone(Point{X: 1, Y: 1, Color: "black"})
+				g.AddSetupStone(Point{X: 3, Y: 3, Color: "white"})
+				g.AddSetupStone(Point{X: 3, Y: 1, Color: "black"})
+			},
+			want: "(;FF[4]GM[1]CA[UTF-8]AP[go-api]SZ[5]KM[7.5]RU[AGA]AB[bb][db]AW[dd]PL[B])\n",
+		},
+		{
+			name:     "counted",
+			settings: Settings{Size: 5, Komi: &komi, Rules: "chinese"},
+			play: func(g *Game) {
+				g.Play(Point{X: 2, Y: 2, Color: "black"})
+				g.Pass()
+				g.Pass()
+				g.FinishCounting()
+			},
+			want: "(;FF[4]GM[1]CA[UTF-8]AP[go-api]SZ[5]KM[0.5]RU[Chinese]RE[B+24.5]\n;B[cc]\n;W[]\n;B[])\n",
+		},
+		{
+			name:     "resigned",
+			settings: Settings{Size: 19, Rules: "nz"},
+			play: func(g *Game) {
+				g.Play(Point{X: 3, Y: 3, Color: "black"})
+				g.Resign("white")
+			},
+			want: "(;FF[4]GM[1]CA[UTF-8]AP[go-api]SZ[19]KM[7]RU[NZ]RE[B+R]\n;B[dd])\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := NewGame(test.settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.play(&g)
+			if got := g.ToSGF(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSGFRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		sgf  string
+	}{
+		{"empty", "(;SZ[9])"},
+		{"rules and komi", "(;SZ[13]KM[0.5]RU[Japanese];B[dd];W[jj])"},
+		{"setup for white", "(;SZ[9]AB[aa:cc]AW[ee]PL[W])"},
+		{"capture", "(;SZ[5];B[ba];W[aa];B[ab])"},
+		{"ko", "(;SZ[5];B[ba];W[ca];B[ab];W[db];B[bc];W[cc];B[cb];W[bb])"},
+		{"passes", "(;SZ[5]RU[Chinese];B[cc];W[];B[dd];W[];B[])"},
+		{"resigned", "(;SZ[9]RE[B+R];B[ee];W[ff])"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := FromSGF(test.sgf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := g.ToSGF()
+			h, err := FromSGF(record)
+			if err != nil {
+				t.Fatalf("reading back %q: %v", record, err)
+			}
+			if got := h.ToSGF(); got != record {
+				t.Errorf("got %q, want %q", got, record)
+			}
+			if got, want := gameState(h), gameState(g); got != want {
+				t.Errorf("state: got %s, want %s", got, want)
+			}
+			if h.Komi != g.Komi || h.Rules != g.Rules {
+				t.Errorf("settings: got %v %+v, want %v %+v", h.Komi, h.Rules, g.Komi, g.Rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// the moves of a game (or its setup stones, with a prefix of "A") as SGF properties
+func sgfMoves(moves []Move, prefix string) string {
+	props := []string{}
+	for _, m := range moves {
+		switch {
+		case m.Resign:
+			props = append(props, colorLetter(m.Color)+"+R")
+		case m.Pass:
+			props = append(props, prefix+colorLetter(m.Color)+"[]")
+		default:
+			props = append(props, prefix+colorLetter(m.Color)+"["+sgfPoint(m.X, m.Y)+"]")
+		}
+	}
+	return strings.Join(props, " ")
+}
+
+func TestFromSGFErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sgf  string
+		err  string
+	}{
+		{"no game tree", "B[aa]", "sgf: offset 0: expected '('"},
+		{"empty game tree", "()", "sgf: offset 2: empty game tree"},
+		{"unexpected end", "(;SZ[9]", "sgf: offset 7: unexpected end of file"},
+		{"unexpected character", "(;SZ[9]x)", "sgf: offset 7: unexpected 'x'"},
+		{"property without a value", "(;SZ 9)", "sgf: offset 5: property SZ has no value"},
+		{"unterminated value", "(;SZ[9];C[oops", "sgf: offset 14: unterminated property value"},
+		{"bad variation", "(;SZ[9](;B[aa])(;W[bb]", "sgf: offset 22: unexpected end of file"},
+		{"not go", "(;GM[2])", "sgf: GM[2] is not a game of Go"},
+		{"rectangular board", "(;SZ[9:7])", "sgf: SZ[9:7]: only square boards are supported"},
+		{"bad komi", "(;SZ[9]KM[lots])", "sgf: KM[lots]: invalid komi"},
+		{"board too small", "(;SZ[1])", "sgf: "},
+		{"move off the board", "(;SZ[9];B[aa];W[jj])", `sgf: node 2: W: invalid point "jj"`},
+		{"short point", "(;SZ[9];B[a])", `sgf: node 1: B: invalid point "a"`},
+		{"tt on a large board", "(;SZ[19];B[aa];W[tt];B[bb])", ""},
+		{"illegal move", "(;SZ[9];B[aa];W[aa])", "sgf: node 2: W[aa]: illegal move white at (0, 0)"},
+		{"setup point off the board", "(;SZ[5]AB[aa][ff])", `sgf: node 0: AB: invalid point "ff"`},
+		{"setup pass", "(;SZ[9]AB[tt])", `sgf: node 0: AB: invalid point "tt"`},
+		{"reversed rectangle", "(;SZ[9]AB[cc:aa])", `sgf: node 0: AB: invalid point "cc:aa"`},
+		{"rectangle with three corners", "(;SZ[9]AB[aa:bb:cc])", `sgf: node 0: AB: invalid point "aa:bb:cc"`},
+		{"setup on an occupied point", "(;SZ[9]AB[aa:bb]AW[bb])", "sgf: node 0: AW[bb]: point is already occupied"},
+		{"setup after a move", "(;SZ[9];B[aa];AW[bb])", "sgf: node 2: setup stones after the first move are not supported"},
+		{"bad player to move", "(;SZ[9]PL[X])", "sgf: node 0: PL[X]: invalid color"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := FromSGF(test.sgf)
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("got error %q, want none", err)
+			case test.err != "" && err == nil:
+				t.Errorf("got no error, want %q", test.err)
+			case test.err != "" && !strings.HasPrefix(err.Error(), test.err):
+				t.Errorf("got error %q, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestFromSGF(t *testing.T) {
+	tests := []struct {
+		name  string
+		sgf   string
+		setup string
+		moves string
+		turn  string
+	}{
+		{
+			name:  "passes",
+			sgf:   "(;SZ[19];B[dd];W[tt];B[])",
+			moves: "B[dd] W[] B[]",
+			turn:  "", // counting
+		},
+		{
+			name:  "tt is a point on boards over 19",
+			sgf:   "(;SZ[21];B[tt])",
+			moves: "B[tt]",
+			turn:  "white",
+		},
+		{
+			name:  "upper case coordinates",
+			sgf:   "(;SZ[30];B[AB])",
+			moves: "B[AB]",
+			turn:  "white",
+		},
+		{
+			name:  "compressed point lists",
+			sgf:   "(;SZ[5]AB[aa:ba][cc:cd]AW[ee])",
+			setup: "AB[aa] AB[ba] AB[cc] AB[cd] AW[ee]",
+			turn:  "black",
+		},
+		{
+			name:  "white to play",
+			sgf:   "(;SZ[9]AB[cc][gg]PL[W])",
+			setup: "AB[cc] AB[gg]",
+			turn:  "white",
+		},
+		{
+			name:  "white to play first",
+			sgf:   "(;SZ[9]AB[cc][gg]PL[W];W[ee];B[ce])",
+			setup: "AB[cc] AB[gg]",
+			moves: "W[ee] B[ce]",
+			turn:  "white",
+		},
+		{
+			name:  "only the first variation",
+			sgf:   "(;SZ[9];B[aa](;W[bb];B[cc](;W[dd])(;W[ee]))(;W[ff]))",
+			moves: "B[aa] W[bb] B[cc] W[dd]",
+			turn:  "black",
+		},
+		{
+			name:  "variations from the root",
+			sgf:   "(;SZ[9](;B[aa];W[bb])(;B[cc]))",
+			moves: "B[aa] W[bb]",
+			turn:  "black",
+		},
+		{
+			name:  "escaped values and whitespace",
+			sgf:   "(\n;SZ[9] C[a \\] b]\n;B [aa]\n;W[bb]\n)",
+			moves: "B[aa] W[bb]",
+			turn:  "black",
+		},
+		{
+			name:  "resigned",
+			sgf:   "(;SZ[9]RE[W+R];B[aa])",
+			moves: "B[aa] B+R",
+			turn:  "white",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := FromSGF(test.sgf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sgfMoves(g.SetupStones, "A"); got != test.setup {
+				t.Errorf("setup: got %q, want %q", got, test.setup)
+			}
+			if got := sgfMoves(g.Moves, ""); got != test.moves {
+				t.Errorf("moves: got %q, want %q", got, test.moves)
+			}
+			if g.Turn != test.turn && !g.Ended {
+				t.Errorf("turn: got %q, want %q", g.Turn, test.turn)
+			}
+		})
+	}
+}
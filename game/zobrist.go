@@ -0,0 +1,117 @@
+package game
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// random keys used to hash board positions (Zobrist hashing)
+// a position's hash is the XOR of the keys of every stone on the board,
+// so it can be updated incrementally as stones are added and removed
+type zobristTable struct {
+	stones [][2]uint64 // indexed by y*size+x, then by color (black, white)
+	turn   [2]uint64   // mixed in to distinguish the player to move
+}
+
+var (
+	zobristMu     sync.Mutex
+	zobristTables = map[int]*zobristTable{}
+)
+
+// keys are generated from a fixed seed so hashes are stable between runs
+func zobristFor(size int) *zobristTable {
+	zobristMu.Lock()
+	defer zobristMu.Unlock()
+	if z, ok := zobristTables[size]; ok {
+		return z
+	}
+	r := rand.New(rand.NewSource(int64(size)))
+	z := &zobristTable{stones: make([][2]uint64, size*size)}
+	for i := range z.stones {
+		z.stones[i] = [2]uint64{r.Uint64(), r.Uint64()}
+	}
+	z.turn = [2]uint64{r.Uint64(), r.Uint64()}
+	zobristTables[size] = z
+	return z
+}
+
+func colorIndex(color string) int {
+	if color == "white" {
+		return 1
+	}
+	return 0
+}
+
+func (z *zobristTable) stone(size, x, y int, color string) uint64 {
+	return z.stones[y*size+x][colorIndex(color)]
+}
+
+// add or remove a stone from the board's hash (XOR is its own inverse)
+func (b *GameBoard) toggleHash(x, y int, color string) {
+	b.Hash ^= zobristFor(b.Size()).stone(b.Size(), x, y, color)
+}
+
+// hash of the board after playing p, without modifying the board
+func (b GameBoard) hashAfter(p Point) uint64 {
+	z := zobristFor(b.Size())
+	hash := b.Hash ^ z.stone(b.Size(), p.X, p.Y, p.Color)
+	captured := map[string]bool{}
+	for _, adjP := range p.AdjPoints(b) {
+		if adjP.Color != OppositeColor(p.Color) || captured[adjP.GroupId] {
+			continue
+		}
+		// an adjacent enemy group whose last liberty is p will be captured
+		grp := b.Groups[adjP.GroupId]
+		if grp.CountLiberties(b) == 1 {
+			captured[grp.ID] = true
+			for _, gp := range grp.Points {
+				hash ^= z.stone(b.Size(), gp.X, gp.Y, grp.Color)
+			}
+		}
+	}
+	if len(captured) > 0 {
+		return hash
+	}
+	// a move which captures nothing and leaves its group without liberties is suicide
+	// (only legal if the rules allow it), taking the stone and the groups it joins off the board
+	own := map[string]*Group{}
+	for _, adjP := range p.AdjPoints(b) {
+		switch adjP.Color {
+		case "":
+			return hash
+		case p.Color:
+			grp := b.Groups[adjP.GroupId]
+			if grp.CountLiberties(b) > 1 {
+				return hash
+			}
+			own[grp.ID] = grp
+		}
+	}
+	hash ^= z.stone(b.Size(), p.X, p.Y, p.Color)
+	for _, grp := range own {
+		for _, gp := range grp.Points {
+			hash ^= z.stone(b.Size(), gp.X, gp.Y, grp.Color)
+		}
+	}
+	return hash
+}
+
+// key identifying a position for the purpose of superko
+// under situational superko the player to move is part of the position
+func (g Game) positionKey(hash uint64, toMove string) uint64 {
+	if g.Rules.Superko == SuperkoSituational {
+		return hash ^ zobristFor(g.Board.Size()).turn[colorIndex(toMove)]
+	}
+	return hash
+}
+
+// check whether playing p would recreate an earlier position
+func (g Game) repeatsPosition(p Point) bool {
+	key := g.positionKey(g.Board.hashAfter(p), OppositeColor(p.Color))
+	for _, prev := range g.Positions {
+		if prev == key {
+			return true
+		}
+	}
+	return false
+}
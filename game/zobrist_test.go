@@ -0,0 +1,91 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// play random legal moves, checking that each move's hash is predicted before it's played
+// and restored when it's taken back
+func checkHashes(t *testing.T, rules string, seed int64) {
+	g, err := NewGame(Settings{Size: 5, Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(seed))
+	for n := 0; n < 100 && !g.Over(); n++ {
+		moves := []Point{}
+		g.Board.ForEachPoint(func(p *Point) {
+			if m := (Point{X: p.X, Y: p.Y, Color: g.Turn}); g.IsValidMove(m) {
+				moves = append(moves, m)
+			}
+		})
+		if len(moves) == 0 {
+			g.Pass()
+			continue
+		}
+		m := moves[r.Intn(len(moves))]
+		before, predicted := g.Board.Hash, g.Board.hashAfter(m)
+		g.Play(m)
+		if g.Board.Hash != predicted {
+			t.Fatalf("move %d: hash %x after playing %+v, predicted %x", n, g.Board.Hash, m, predicted)
+		}
+		g.Undo()
+		if g.Board.Hash != before {
+			t.Fatalf("move %d: hash %x after undoing %+v, want %x", n, g.Board.Hash, m, before)
+		}
+		g.Redo()
+	}
+}
+
+func TestHashAfter(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		checkHashes(t, "japanese", seed)
+	}
+	// random play under rules allowing suicide will play some
+	for seed := int64(1); seed <= 20; seed++ {
+		checkHashes(t, "nz", seed)
+	}
+}
+
+func TestSuperko(t *testing.T) {
+	// a move which repeats an earlier position, but isn't a simple ko recapture
+	const repeat = "(;SZ[3]RU[%s];B[bb];W[cb];B[ba];W[ca];B[cc];W[bc];B[ab];W[ca];B[];W[cb])"
+	// the same board with white to move as after black passed
+	const afterPass = "(;SZ[3]RU[%s];B[aa];W[];B[ab];W[];B[bc];W[bb];B[cb];W[ba];B[];W[ac];B[aa];W[])"
+	// the same board as before, but with the other player to move
+	const otherTurn = "(;SZ[3]RU[%s];B[];W[bb];B[aa];W[ca];B[cc];W[];B[ac];W[];B[ba];W[bc];B[cb];W[ca])"
+	tests := []struct {
+		name   string
+		record string
+		move   Point
+		rules  map[string]bool // whether the move is legal under each rule set
+	}{
+		{"repeated position", repeat, Point{X: 2, Y: 2, Color: "black"},
+			map[string]bool{"Japanese": true, "Chinese": false, "AGA": false}},
+		{"repeated situation after a pass", afterPass, Point{X: 0, Y: 1, Color: "black"},
+			map[string]bool{"Japanese": true, "Chinese": false, "AGA": false}},
+		{"repeated board with the other player to move", otherTurn, Point{X: 2, Y: 2, Color: "black"},
+			map[string]bool{"Japanese": true, "Chinese": false, "AGA": true}},
+	}
+	for _, test := range tests {
+		for rules, legal := range test.rules {
+			t.Run(test.name+"/"+rules, func(t *testing.T) {
+				g, err := FromSGF(fmt.Sprintf(test.record, rules))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := g.IsValidMove(test.move); got != legal {
+					t.Errorf("got legal %v, want %v", got, legal)
+				}
+				// taking back the last move forgets the position it reached
+				g.Undo()
+				g.Redo()
+				if got := g.IsValidMove(test.move); got != legal {
+					t.Errorf("after undo and redo: got legal %v, want %v", got, legal)
+				}
+			})
+		}
+	}
+}
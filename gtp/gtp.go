@@ -0,0 +1,395 @@
+// Package gtp runs the engine as a Go Text Protocol (version 2) program,
+// so it can be driven by GoGui, Sabaki, twogtp and similar tools.
+package gtp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api/game"
+	"go-api/player"
+)
+
+const columns = "ABCDEFGHJKLMNOPQRSTUVWXYZ" // GTP skips the letter I
+
+type Engine struct {
+	settings game.Settings
+	game     game.Game
+	timed    bool             // whether time_settings has set a time limit
+	byoYomi  time.Duration    // byo-yomi period (0 for none), from time_settings
+	stones   int              // stones to be played in each byo-yomi period
+	clocks   map[string]clock // each color's time left
+}
+
+// time a player has left: main time if stones is 0, otherwise time for that many stones of byo-yomi
+type clock struct {
+	left   time.Duration
+	stones int
+}
+
+func NewEngine() *Engine {
+	e := &Engine{settings: game.DefaultSettings}
+	e.game, _ = game.NewGame(e.settings)
+	return e
+}
+
+type handler func(e *Engine, args []string) (string, error)
+
+var commands map[string]handler
+
+func init() {
+	commands = map[string]handler{
+		"protocol_version": func(e *Engine, args []string) (string, error) { return "2", nil },
+		"name":             func(e *Engine, args []string) (string, error) { return "go-api", nil },
+		"version":          func(e *Engine, args []string) (string, error) { return "1.0", nil },
+		"known_command":    knownCommand,
+		"list_commands":    listCommands,
+		"quit":             func(e *Engine, args []string) (string, error) { return "", nil },
+		"boardsize":        boardSize,
+		"clear_board":      clearBoard,
+		"komi":             komi,
+		"play":             play,
+		"genmove":          genMove,
+		"time_settings":    timeSettings,
+		"time_left":        timeLeft,
+		"undo":             undo,
+		"final_score":      finalScore,
+		"showboard":        showBoard,
+	}
+}
+
+// Run reads commands from r and writes responses to w until quit or end of input
+func Run(r io.Reader, w io.Writer) error {
+	e := NewEngine()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := preprocess(scanner.Text())
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		id := ""
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			id, fields = fields[0], fields[1:]
+			if len(fields) == 0 {
+				continue
+			}
+		}
+		name, args := fields[0], fields[1:]
+
+		var response string
+		var err error
+		if cmd, ok := commands[name]; ok {
+			response, err = cmd(e, args)
+		} else {
+			err = fmt.Errorf("unknown command")
+		}
+		if err != nil {
+			fmt.Fprintf(w, "?%s %s\n\n", id, err)
+		} else if response == "" {
+			fmt.Fprintf(w, "=%s\n\n", id)
+		} else {
+			fmt.Fprintf(w, "=%s %s\n\n", id, response)
+		}
+		if name == "quit" {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// strip comments and control characters, and convert tabs to spaces
+func preprocess(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r < 32 || r == 127:
+			return -1
+		}
+		return r
+	}, line)
+}
+
+func parseColor(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "b", "black":
+		return "black", nil
+	case "w", "white":
+		return "white", nil
+	}
+	return "", fmt.Errorf("invalid color")
+}
+
+// parse a vertex such as "D4" (columns from the left, rows from the bottom)
+// pass is reported as (-1, -1)
+func parseVertex(s string, size int) (x, y int, err error) {
+	s = strings.ToUpper(s)
+	if s == "PASS" {
+		return -1, -1, nil
+	}
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("invalid vertex")
+	}
+	x = strings.IndexByte(columns, s[0])
+	row, err := strconv.Atoi(s[1:])
+	if x < 0 || x >= size || err != nil || row < 1 || row > size {
+		return 0, 0, fmt.Errorf("invalid vertex")
+	}
+	return x, size - row, nil
+}
+
+func formatVertex(x, y, size int) string {
+	if x < 0 || y < 0 {
+		return "pass"
+	}
+	return fmt.Sprintf("%c%d", columns[x], size-y)
+}
+
+func knownCommand(e *Engine, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	_, ok := commands[args[0]]
+	return strconv.FormatBool(ok), nil
+}
+
+func listCommands(e *Engine, args []string) (string, error) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "\n"), nil
+}
+
+func boardSize(e *Engine, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	size, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	if size > len(columns) {
+		return "", fmt.Errorf("unacceptable size")
+	}
+	settings := e.settings
+	settings.Size = size
+	g, err := game.NewGame(settings)
+	if err != nil {
+		return "", fmt.Errorf("unacceptable size")
+	}
+	e.settings, e.game = settings, g
+	return "", nil
+}
+
+func clearBoard(e *Engine, args []string) (string, error) {
+	g, err := game.NewGame(e.settings)
+	if err != nil {
+		return "", err
+	}
+	e.game = g
+	return "", nil
+}
+
+func komi(e *Engine, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	k, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	e.settings.Komi = &k
+	e.game.Komi = k
+	return "", nil
+}
+
+func play(e *Engine, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("syntax error")
+	}
+	color, err := parseColor(args[0])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	x, y, err := parseVertex(args[1], e.game.Board.Size())
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	if e.game.Over() {
+		return "", fmt.Errorf("illegal move")
+	}
+	// the controller decides who moves, so either color may play at any time
+	e.game.Turn = color
+	if x < 0 {
+		e.game.Pass()
+		return "", nil
+	}
+	p := game.Point{X: x, Y: y, Color: color}
+	if !e.game.IsValidMove(p) {
+		return "", fmt.Errorf("illegal move")
+	}
+	e.game.Play(p)
+	return "", nil
+}
+
+func genMove(e *Engine, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+	color, err := parseColor(args[0])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	if e.game.Over() {
+		return "pass", nil
+	}
+	e.game.Turn = color
+	limit := e.thinkTime(color)
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	start := time.Now()
+	move := player.SearchContext(ctx, e.game, color, player.DefaultConfig, player.DefaultSearchConfig).Move
+	cancel()
+	e.spend(color, time.Since(start))
+	if move.X < 0 || !e.game.IsValidMove(move) {
+		e.game.Pass()
+		return "pass", nil
+	}
+	e.game.Play(move)
+	return formatVertex(move.X, move.Y, e.game.Board.Size()), nil
+}
+
+// time_settings main_time byo_yomi_time byo_yomi_stones (in seconds)
+func timeSettings(e *Engine, args []string) (string, error) {
+	if len(args) < 3 {
+		return "", fmt.Errorf("syntax error")
+	}
+	main, err1 := strconv.Atoi(args[0])
+	byoYomi, err2 := strconv.Atoi(args[1])
+	stones, err3 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil || err3 != nil || main < 0 || byoYomi < 0 || stones < 0 {
+		return "", fmt.Errorf("syntax error")
+	}
+	// byo-yomi time with no stones to play in it means no time limit
+	e.timed = byoYomi == 0 || stones > 0
+	e.byoYomi, e.stones = time.Duration(byoYomi)*time.Second, stones
+	start := clock{left: time.Duration(main) * time.Second}
+	if main == 0 {
+		start = clock{left: e.byoYomi, stones: stones}
+	}
+	e.clocks = map[string]clock{"black": start, "white": start}
+	return "", nil
+}
+
+// time_left color time stones: the controller's view of a player's clock
+func timeLeft(e *Engine, args []string) (string, error) {
+	if len(args) < 3 {
+		return "", fmt.Errorf("syntax error")
+	}
+	color, err := parseColor(args[0])
+	if err != nil {
+		return "", fmt.Errorf("syntax error")
+	}
+	left, err1 := strconv.Atoi(args[1])
+	stones, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil || left < 0 || stones < 0 {
+		return "", fmt.Errorf("syntax error")
+	}
+	if e.clocks == nil {
+		e.clocks = map[string]clock{}
+	}
+	e.clocks[color] = clock{left: time.Duration(left) * time.Second, stones: stones}
+	return "", nil
+}
+
+// how long color may think about its next move, leaving a margin for communication
+// in main time a fraction of what's left is used, in byo-yomi an even share of the period
+func (e *Engine) thinkTime(color string) time.Duration {
+	if !e.timed {
+		return player.DefaultSearchTime
+	}
+	c := e.clocks[color]
+	var limit time.Duration
+	switch {
+	case c.stones > 0:
+		limit = c.left / time.Duration(c.stones)
+	case e.stones > 0:
+		limit = c.left/30 + e.byoYomi/time.Duration(e.stones)
+	default:
+		limit = c.left / 30
+	}
+	limit = limit * 8 / 10
+	if limit < 100*time.Millisecond {
+		limit = 100 * time.Millisecond
+	}
+	return limit
+}
+
+// take the time spent on a move off color's clock, until the controller reports it again
+func (e *Engine) spend(color string, d time.Duration) {
+	if !e.timed {
+		return
+	}
+	c := e.clocks[color]
+	c.left -= d
+	if c.stones > 0 {
+		c.stones--
+		if c.stones == 0 || c.left <= 0 {
+			c = clock{left: e.byoYomi, stones: e.stones}
+		}
+	} else if c.left <= 0 && e.stones > 0 {
+		c = clock{left: e.byoYomi, stones: e.stones}
+	}
+	e.clocks[color] = c
+}
+
+func undo(e *Engine, args []string) (string, error) {
+	if !e.game.Undo() {
+		return "", fmt.Errorf("cannot undo")
+	}
+	return "", nil
+}
+
+func finalScore(e *Engine, args []string) (string, error) {
+	_, result := e.game.CountResult()
+	if result == "Draw" {
+		return "0", nil
+	}
+	return result, nil
+}
+
+func showBoard(e *Engine, args []string) (string, error) {
+	size := e.game.Board.Size()
+	var sb strings.Builder
+	header := "   " + strings.Join(strings.Split(columns[:size], ""), " ")
+	sb.WriteString("\n" + header + "\n")
+	for y := 0; y < size; y++ {
+		fmt.Fprintf(&sb, "%2d ", size-y)
+		for x := 0; x < size; x++ {
+			switch e.game.Board.At(x, y).Color {
+			case "black":
+				sb.WriteString("X ")
+			case "white":
+				sb.WriteString("O ")
+			default:
+				sb.WriteString(". ")
+			}
+		}
+		fmt.Fprintf(&sb, "%d\n", size-y)
+	}
+	sb.WriteString(header + "\n")
+	fmt.Fprintf(&sb, "captured black: %d  captured white: %d  to move: %s",
+		e.game.Captures["black"], e.game.Captures["white"], e.game.Turn)
+	return sb.String(), nil
+}
@@ -0,0 +1,153 @@
+package gtp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/player"
+)
+
+// feed the lines to a new engine, returning everything it wrote
+func run(t *testing.T, lines ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Run(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string // responses, one per line
+	}{
+		{"ids", []string{"1 name", "version", "22 protocol_version"}, "=1 go-api|= 1.0|=22 2"},
+		{"unknown command", []string{"3 frobnicate", "frobnicate"}, "?3 unknown command|? unknown command"},
+		{"comments and blank lines", []string{"# a comment", "", "  ", "4 name # the name", "5"}, "=4 go-api"},
+		{"tabs and control characters", []string{"6\tknown_command\tplay\r"}, "=6 true"},
+		{"known_command", []string{"known_command genmove", "known_command frobnicate", "known_command"}, "= true|= false|? syntax error"},
+		{
+			"list_commands",
+			[]string{"list_commands"},
+			"= boardsize\nclear_board\nfinal_score\ngenmove\nknown_command\nkomi\nlist_commands\nname\n" +
+				"play\nprotocol_version\nquit\nshowboard\ntime_left\ntime_settings\nundo\nversion",
+		},
+		{"quit", []string{"7 quit", "name"}, "=7"},
+		{"boardsize", []string{"boardsize 5", "play b E5", "play b F1"}, "=|=|? syntax error"},
+		{"boardsize clears the board", []string{"play b A1", "boardsize 9", "play w A1"}, "=|=|="},
+		{"boardsize out of range", []string{"boardsize 1", "boardsize 26", "boardsize"}, "? unacceptable size|? unacceptable size|? syntax error"},
+		{"boardsize not a number", []string{"boardsize nine"}, "? syntax error"},
+		{"clear_board", []string{"play b A1", "clear_board", "play w A1"}, "=|=|="},
+		{"komi", []string{"komi 0.5", "play b E5", "final_score"}, "=|=|= B+80.5"},
+		{"komi is kept by clear_board", []string{"komi 6.5", "clear_board", "play b E5", "final_score"}, "=|=|=|= B+74.5"},
+		{"komi not a number", []string{"komi lots"}, "? syntax error"},
+		{"draw", []string{"komi 0", "final_score"}, "=|= 0"},
+		{"play", []string{"play b D4", "play white G7", "play B pass"}, "=|=|="},
+		{"play either color at any time", []string{"play w D4", "play w E4"}, "=|="},
+		{"play on an occupied point", []string{"play b D4", "play w D4"}, "=|? illegal move"},
+		{"play suicide", []string{"play b A2", "play b B1", "play w A1"}, "=|=|? illegal move"},
+		{"play after both passed", []string{"play b pass", "play w pass", "play b D4"}, "=|=|? illegal move"},
+		{
+			"play syntax errors",
+			[]string{"play b", "play x D4", "play b Z9", "play b I1", "play b D0", "play b D10"},
+			"? syntax error|? syntax error|? syntax error|? syntax error|? syntax error|? syntax error",
+		},
+		{"genmove syntax errors", []string{"genmove", "genmove x"}, "? syntax error|? syntax error"},
+		{"genmove after both passed", []string{"play b pass", "play w pass", "genmove b"}, "=|=|= pass"},
+		{"undo", []string{"play b D4", "undo", "play w D4", "undo", "undo"}, "=|=|=|=|? cannot undo"},
+		{"time_settings", []string{"time_settings 300 30 5", "time_settings 0 0 0"}, "=|="},
+		{
+			"time_settings syntax errors",
+			[]string{"time_settings 300 30", "time_settings -1 30 5", "time_settings 300 x 5"},
+			"? syntax error|? syntax error|? syntax error",
+		},
+		{"time_left", []string{"time_left b 60 0", "time_left white 30 5"}, "=|="},
+		{
+			"time_left syntax errors",
+			[]string{"time_left b 60", "time_left x 60 0", "time_left b -1 0", "time_left b 60 x"},
+			"? syntax error|? syntax error|? syntax error|? syntax error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := strings.ReplaceAll(test.want, "|", "\n\n") + "\n\n"
+			if got := run(t, test.lines...); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestGenMove(t *testing.T) {
+	// a tenth of a second for each move
+	out := run(t, "boardsize 5", "time_settings 0 1 10", "1 genmove b", "2 genmove w")
+	responses := strings.Split(strings.TrimSuffix(out, "\n\n"), "\n\n")
+	if len(responses) != 4 {
+		t.Fatalf("got responses %q, want 4", responses)
+	}
+	// replay the moves chosen, which must have been legal
+	lines := []string{"boardsize 5"}
+	for i, color := range []string{"b", "w"} {
+		id := string(rune('1' + i))
+		vertex := strings.TrimPrefix(responses[2+i], "="+id+" ")
+		if vertex == responses[2+i] {
+			t.Fatalf("got response %q to genmove %s", responses[2+i], color)
+		}
+		if _, _, err := parseVertex(vertex, 5); err != nil {
+			t.Fatalf("got vertex %q from genmove %s", vertex, color)
+		}
+		lines = append(lines, "play "+color+" "+vertex)
+	}
+	if got := run(t, lines...); got != "=\n\n=\n\n=\n\n" {
+		t.Errorf("replaying %q got %q", lines, got)
+	}
+}
+
+func TestThinkTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		spend []time.Duration // time black spends on moves after the commands
+		color string
+		want  time.Duration
+	}{
+		{"untimed", nil, nil, "black", player.DefaultSearchTime},
+		{"byo-yomi time without stones is untimed", []string{"time_settings 300 30 0"}, nil, "black", player.DefaultSearchTime},
+		{"main time", []string{"time_settings 300 0 0"}, nil, "black", 8 * time.Second},
+		{"main time and byo-yomi", []string{"time_settings 300 30 5"}, nil, "black", 12800 * time.Millisecond},
+		{"byo-yomi", []string{"time_settings 0 30 5"}, nil, "white", 4800 * time.Millisecond},
+		{"time_left", []string{"time_settings 0 30 5", "time_left w 20 4"}, nil, "white", 4 * time.Second},
+		{"time_left for the other color", []string{"time_settings 0 30 5", "time_left w 20 4"}, nil, "black", 4800 * time.Millisecond},
+		{"time running out", []string{"time_settings 300 0 0", "time_left b 3 0"}, nil, "black", 100 * time.Millisecond},
+		{"time spent", []string{"time_settings 0 30 5"}, []time.Duration{2 * time.Second}, "black", 5600 * time.Millisecond},
+		{
+			"new byo-yomi period",
+			[]string{"time_settings 0 30 2"},
+			[]time.Duration{5 * time.Second, 5 * time.Second},
+			"black",
+			12 * time.Second,
+		},
+		{"main time used up", []string{"time_settings 10 30 5"}, []time.Duration{11 * time.Second}, "black", 4800 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewEngine()
+			for _, line := range test.lines {
+				fields := strings.Fields(line)
+				if _, err := commands[fields[0]](e, fields[1:]); err != nil {
+					t.Fatalf("%s: %v", line, err)
+				}
+			}
+			for _, d := range test.spend {
+				e.spend("black", d)
+			}
+			if got := e.thinkTime(test.color); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -1,48 +1,220 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 
 	"go-api/game"
+	"go-api/gtp"
 	"go-api/player"
+	"go-api/storage"
 )
 
 func main() {
-	Game = handleNewGame(9)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "gtp":
+			if err := gtp.Run(os.Stdin, os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "selfplay":
+			runSelfPlay(os.Args[2:])
+			return
+		case "tune":
+			runTune(os.Args[2:])
+			return
+		case "train":
+			runTrain(os.Args[2:])
+			return
+		}
+	}
+
+	Games = newRegistry(openStore())
+	if err := Games.restore(); err != nil {
+		log.Fatalf("loading games: %v", err)
+	}
+
+	if path := os.Getenv("NETWORK_WEIGHTS"); path != "" {
+		network, err := player.LoadNetwork(path)
+		if err != nil {
+			log.Fatalf("loading network: %v", err)
+		}
+		Network = network
+	}
+
+	// memory given to each minimax search's transposition table
+	if size := os.Getenv("SEARCH_TABLE_MB"); size != "" {
+		mb, err := strconv.Atoi(size)
+		if err != nil || mb < 0 {
+			log.Fatalf("invalid SEARCH_TABLE_MB %q", size)
+		}
+		player.DefaultSearchConfig.TableSize = mb
+	}
+	// goroutines each minimax search is spread across (every CPU by default)
+	if workers := os.Getenv("SEARCH_WORKERS"); workers != "" {
+		n, err := strconv.Atoi(workers)
+		if err != nil || n < 1 {
+			log.Fatalf("invalid SEARCH_WORKERS %q", workers)
+		}
+		player.DefaultSearchConfig.Workers = n
+	}
+	// extra named evaluator configs, added to the presets
+	if path := os.Getenv("EVAL_CONFIGS"); path != "" {
+		if err := player.LoadPresets(path); err != nil {
+			log.Fatalf("loading evaluator configs: %v", err)
+		}
+	}
+
 	router := gin.Default()
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"*"}
 	router.Use(cors.New(config))
-	router.GET("/board", getBoard)
-	router.GET("/groups", getGroups)
-	router.GET("/captures", getCaptures)
-	router.GET("/score", getScore)
-	router.GET("/ko", getKo)
-	router.GET("/active-player", getActivePlayer)
-	router.GET("/game", getGame)
-	router.GET("/new-game", getNewGame)
-	router.GET("/pass", getPass)
-	router.GET("/resign", getResign)
-	router.GET("/player-move/:color", getPlayerMove)
-	router.GET("/random-move/:color", getRandomMove)
-	router.POST("/moves", postMove)
+	router.GET("/games", getGames)
+	router.POST("/games", postGame)
+	router.POST("/games/sgf", postGameSGF)
+	router.GET("/configs", getConfigs)
+	router.GET("/levels", getLevels)
+	// the socket stays open indefinitely, so it locks the game only while handling a message
+	router.GET("/games/:id/ws", getGameSocket)
+	games := router.Group("/games/:id", loadSession)
+	games.GET("/board", getBoard)
+	games.GET("/groups", getGroups)
+	games.GET("/captures", getCaptures)
+	games.GET("/score", getScore)
+	games.GET("/ko", getKo)
+	games.GET("/active-player", getActivePlayer)
+	games.GET("/game", getGame)
+	games.GET("/game.sgf", getGameSGF)
+	games.GET("/new-game", getNewGame)
+	games.POST("/new-game", getNewGame)
+	games.GET("/pass", getPass)
+	games.GET("/resign", getResign)
+	games.POST("/dead", postDead)
+	games.POST("/accept/:color", postAccept)
+	games.POST("/resume", postResume)
+	games.GET("/config", getConfig)
+	games.POST("/config", postConfig)
+	games.GET("/player-move/:color", getPlayerMove)
+	games.GET("/analyze", getAnalyze)
+	games.GET("/status-estimate", getStatusEstimate)
+	games.GET("/random-move/:color", getRandomMove)
+	games.GET("/moves", getMoves)
+	games.POST("/moves", postMove)
+	games.GET("/undo", getUndo)
+	games.GET("/redo", getRedo)
 	router.Run("0.0.0.0:8080")
 }
 
-var Game game.Game
+// every game currently being played, keyed by ID
+var Games *registry
+
+// policy/value network used when a move is requested with ?eval=network
+// loaded at startup from the file named by NETWORK_WEIGHTS, if set
+var Network *player.Network
+
+// games are saved to PostgreSQL when DATABASE_URL is set, otherwise kept in memory
+func openStore() storage.Store {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		return storage.NewMemoryStore()
+	}
+	store, err := storage.OpenPostgres(dsn)
+	if err != nil {
+		log.Fatalf("connecting to database: %v", err)
+	}
+	return store
+}
+
+// register a new game and respond with its ID
+func createGame(c *gin.Context, g game.Game) {
+	id, _, err := Games.create(g)
+	if err != nil {
+		log.Printf("creating game: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "Internal Server Error", "message": "could not save game"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
 
-func handleNewGame(size int) game.Game {
-	Game = game.NewGame(9)
-	return Game
+// create a game from the (optional) JSON settings in the request body
+func handleNewGame(c *gin.Context) (game.Game, bool) {
+	settings := game.DefaultSettings
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&settings); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "invalid JSON data"})
+			return game.Game{}, false
+		}
+	}
+	if _, ok := player.Levels[settings.Level]; settings.Level != "" && !ok {
+		message := fmt.Sprintf("unknown level, choose from %s", strings.Join(player.LevelNames(), ", "))
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": message})
+		return game.Game{}, false
+	}
+	g, err := game.NewGame(settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": err.Error()})
+		return game.Game{}, false
+	}
+	return g, true
+}
+
+func getGames(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, Games.ids())
+}
+
+func postGame(c *gin.Context) {
+	newGame, ok := handleNewGame(c)
+	if !ok {
+		return
+	}
+	createGame(c, newGame)
+}
+
+// load a game from an SGF record, sent either as the request body
+// or as the "file" field of a multipart form
+func postGameSGF(c *gin.Context) {
+	var data []byte
+	var err error
+	if c.ContentType() == "multipart/form-data" {
+		var file *multipart.FileHeader
+		var f multipart.File
+		if file, err = c.FormFile("file"); err == nil {
+			if f, err = file.Open(); err == nil {
+				data, err = ioutil.ReadAll(f)
+				f.Close()
+			}
+		}
+	} else {
+		data, err = c.GetRawData()
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "could not read SGF data"})
+		return
+	}
+	newGame, err := game.FromSGF(string(data))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": err.Error()})
+		return
+	}
+	createGame(c, newGame)
 }
 
 func handleMove(c *gin.Context, p *game.Point) {
-	if Game.IsValidMove(*p) {
-		Game.Play(*p)
-		c.JSON(http.StatusOK, *Game.Board.At(p.X, p.Y))
+	s := currentSession(c)
+	if s.play(*p) {
+		c.JSON(http.StatusOK, *s.game.Board.At(p.X, p.Y))
 
 	} else if p.X == -1 || p.Y == -1 {
 		getPass(c)
@@ -51,32 +223,286 @@ func handleMove(c *gin.Context, p *game.Point) {
 	}
 }
 
+// ask the AI for a move: games against the computer created with a level are played
+// at that level unless an engine is given, otherwise ?engine=minimax (default) or ?engine=mcts,
+// with ?eval=static (default) or ?eval=network for minimax
+// (static evaluation uses the game's config, or the preset named by ?config=),
+// an optional ?time= (e.g. "2s") limit and an optional ?playouts= limit for mcts
 func getPlayerMove(c *gin.Context) {
 	color := c.Param("color")
-	move := player.Move(Game, color)
+	g := currentGame(c)
+	var move game.Point
+	engine, ok := c.GetQuery("engine")
+	if !ok && g.Level != "" {
+		engine = "level"
+	} else if !ok {
+		engine = "minimax"
+	}
+	switch engine {
+	case "level":
+		ctx, cancel, ok := searchContext(c, g)
+		if !ok {
+			return
+		}
+		defer cancel()
+		move = player.LevelMove(ctx, *g, color, player.Levels[g.Level])
+	case "minimax":
+		evaluator, ok := chooseEvaluator(c, g)
+		if !ok {
+			return
+		}
+		ctx, cancel, ok := searchContext(c, g)
+		if !ok {
+			return
+		}
+		defer cancel()
+		move = player.SearchContext(ctx, *g, color, evaluator, player.DefaultSearchConfig).Move
+	case "mcts":
+		config := player.DefaultMCTSConfig
+		if playouts, ok := c.GetQuery("playouts"); ok {
+			n, err := strconv.Atoi(playouts)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "playouts invalid"})
+				return
+			}
+			config.Playouts = n
+		}
+		limit, ok := moveTime(c, g, config.TimeLimit)
+		if !ok {
+			return
+		}
+		config.TimeLimit = limit
+		var err error
+		if move, err = player.MCTSMove(*g, color, config); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": err.Error()})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "unknown engine"})
+		return
+	}
 	handleMove(c, &move)
 }
 
+// the minimax evaluator requested with ?eval= and ?config= (see getPlayerMove)
+func chooseEvaluator(c *gin.Context, g *game.Game) (player.Evaluator, bool) {
+	var evaluator player.Evaluator = currentSession(c).config
+	switch c.DefaultQuery("eval", "static") {
+	case "static":
+		if name, ok := c.GetQuery("config"); ok {
+			config, ok := player.Presets[name]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "unknown config"})
+				return nil, false
+			}
+			evaluator = config
+		}
+	case "network":
+		if Network == nil || Network.Size() != g.Board.Size() {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "no network loaded for this board size"})
+			return nil, false
+		}
+		evaluator = Network
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "unknown evaluator"})
+		return nil, false
+	}
+	return evaluator, true
+}
+
+// context for a minimax search, which ends when the time is up (see moveTime) or the client goes away
+func searchContext(c *gin.Context, g *game.Game) (context.Context, context.CancelFunc, bool) {
+	limit, ok := moveTime(c, g, player.DefaultSearchTime)
+	if !ok {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), limit)
+	return ctx, cancel, true
+}
+
+// analyse the position for the player to move, returning the best ?n= (default 5) moves
+// with their scores and expected lines of play
+// the evaluator and time limit are chosen as for a minimax move (see getPlayerMove)
+func getAnalyze(c *gin.Context) {
+	g := currentGame(c)
+	if g.Over() {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game has ended"})
+		return
+	}
+	n, err := strconv.Atoi(c.DefaultQuery("n", "5"))
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "n invalid"})
+		return
+	}
+	evaluator, ok := chooseEvaluator(c, g)
+	if !ok {
+		return
+	}
+	ctx, cancel, ok := searchContext(c, g)
+	if !ok {
+		return
+	}
+	defer cancel()
+	c.IndentedJSON(http.StatusOK, player.Analyze(ctx, *g, evaluator, player.DefaultSearchConfig, n))
+}
+
+// estimate whether each group is alive, dead or in seki, from at most ?playouts= (default 200)
+// playouts run within the ?time= limit (default 2s)
+func getStatusEstimate(c *gin.Context) {
+	config := player.DefaultStatusConfig
+	if playouts, ok := c.GetQuery("playouts"); ok {
+		n, err := strconv.Atoi(playouts)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "playouts invalid"})
+			return
+		}
+		config.Playouts = n
+	}
+	if limit, ok := c.GetQuery("time"); ok {
+		d, err := time.ParseDuration(limit)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "time invalid"})
+			return
+		}
+		config.TimeLimit = d
+	}
+	c.IndentedJSON(http.StatusOK, player.EstimateStatus(*currentGame(c), config))
+}
+
+// how long the computer may think about a move: ?time= if given (which must be positive),
+// otherwise the game's move time, otherwise the fallback
+func moveTime(c *gin.Context, g *game.Game, fallback time.Duration) (time.Duration, bool) {
+	if limit, ok := c.GetQuery("time"); ok {
+		d, err := time.ParseDuration(limit)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "time invalid"})
+			return 0, false
+		}
+		return d, true
+	}
+	if g.MoveTime > 0 {
+		return time.Duration(g.MoveTime * float64(time.Second)), true
+	}
+	return fallback, true
+}
+
+// every difficulty level
+func getLevels(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, player.Levels)
+}
+
+// every named evaluator config
+func getConfigs(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, player.Presets)
+}
+
+// the evaluator config the AI uses in this game
+func getConfig(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, currentSession(c).config)
+}
+
+// choose the game's evaluator config: a preset named by ?preset=,
+// or a JSON config in the request body (weights left out keep their default)
+func postConfig(c *gin.Context) {
+	var config player.EvalConfig
+	if name, ok := c.GetQuery("preset"); ok {
+		if config, ok = player.Presets[name]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "unknown config"})
+			return
+		}
+	} else {
+		data, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "could not read config"})
+			return
+		}
+		if config, err = player.ParseEvalConfig(data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": err.Error()})
+			return
+		}
+	}
+	currentSession(c).setConfig(config)
+	c.IndentedJSON(http.StatusOK, config)
+}
+
 func getRandomMove(c *gin.Context) {
 	color := c.Param("color")
-	move := player.RandomMove(Game, color)
+	move := player.RandomMove(*currentGame(c), color)
 	handleMove(c, &move)
 }
 
 func getResign(c *gin.Context) {
-	Game.Resign(Game.Turn)
+	s := currentSession(c)
+	if s.game.Counting {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game is being counted"})
+		return
+	}
+	if !s.resign() {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game has ended"})
+		return
+	}
 	c.JSON(http.StatusOK, "Game Over")
 }
 
+// pass; after both players pass in a row the game is counted (see postDead)
 func getPass(c *gin.Context) {
-	Game.Pass()
-	if Game.Ended {
-		c.JSON(http.StatusOK, "Game Over")
+	s := currentSession(c)
+	if s.game.Over() {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game has ended"})
+		return
+	}
+	s.pass()
+	if s.game.Counting {
+		c.JSON(http.StatusOK, "Counting")
 	} else {
-		c.JSON(http.StatusOK, Game.Turn)
+		c.JSON(http.StatusOK, s.game.Turn)
 	}
 }
 
+// while the game is being counted, mark the group with a stone at the posted point dead,
+// or alive again if it was marked dead, returning the count as it now stands
+func postDead(c *gin.Context) {
+	var p game.Point
+	if err := c.BindJSON(&p); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "invalid JSON data"})
+		return
+	}
+	s := currentSession(c)
+	if !s.game.Counting {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game is not being counted"})
+		return
+	}
+	if !s.toggleDead(p.X, p.Y) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "no stone there"})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, s.game.Count())
+}
+
+// accept the count for a color; once both have, the game ends with the marked stones captured
+func postAccept(c *gin.Context) {
+	s := currentSession(c)
+	if !s.game.Counting {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game is not being counted"})
+		return
+	}
+	if !s.accept(c.Param("color")) {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "color invalid or count already accepted"})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, simplifyGame(s.game))
+}
+
+// stop counting and carry on playing, so the players can settle a disagreement on the board
+func postResume(c *gin.Context) {
+	s := currentSession(c)
+	if !s.resume() {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game is not being counted"})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, simplifyGame(s.game))
+}
+
 func postMove(c *gin.Context) {
 	var newPoint game.Point
 	if err := c.BindJSON(&newPoint); err != nil {
@@ -86,38 +512,78 @@ func postMove(c *gin.Context) {
 	handleMove(c, &newPoint)
 }
 
+func getMoves(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, currentGame(c).Moves)
+}
+
+func getUndo(c *gin.Context) {
+	g := currentGame(c)
+	if !g.Undo() {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "no move to undo"})
+		return
+	}
+	currentSession(c).changed(eventUndo)
+	c.IndentedJSON(http.StatusOK, simplifyGame(*g))
+}
+
+func getRedo(c *gin.Context) {
+	g := currentGame(c)
+	if !g.Redo() {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "no move to redo"})
+		return
+	}
+	currentSession(c).changed(eventRedo)
+	c.IndentedJSON(http.StatusOK, simplifyGame(*g))
+}
+
 func getNewGame(c *gin.Context) {
-	Game = handleNewGame(9)
+	newGame, ok := handleNewGame(c)
+	if !ok {
+		return
+	}
+	g := currentGame(c)
+	newGame.ID, newGame.Board.ID = g.ID, g.ID
+	// the AI's config was chosen for this game ID, not the game (see postConfig)
+	newGame.EvalConfig = g.EvalConfig
+	*g = newGame
+	currentSession(c).changed(eventNewGame)
 	c.JSON(http.StatusOK, "")
 }
 
 // simplify gameboard before sending to client
 func getBoard(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, simplifyBoard(Game.Board))
+	c.IndentedJSON(http.StatusOK, simplifyBoard(currentGame(c).Board))
 }
 
 func getGroups(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, Game.Board.Groups)
+	c.IndentedJSON(http.StatusOK, currentGame(c).Board.Groups)
 }
 
 func getCaptures(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, Game.Captures)
+	c.IndentedJSON(http.StatusOK, currentGame(c).Captures)
 }
 
+// the score as the board stands, broken down under the game's scoring system
 func getScore(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, Game.Score)
+	c.IndentedJSON(http.StatusOK, currentGame(c).Count())
 }
 
 func getKo(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, Game.Ko)
+	c.IndentedJSON(http.StatusOK, currentGame(c).Ko)
 }
 
 func getActivePlayer(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, Game.Turn)
+	c.IndentedJSON(http.StatusOK, currentGame(c).Turn)
 }
 
 func getGame(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, simplifyGame(Game))
+	c.IndentedJSON(http.StatusOK, simplifyGame(*currentGame(c)))
+}
+
+func getGameSGF(c *gin.Context) {
+	g := currentGame(c)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=game-%d.sgf", g.ID))
+	c.Data(http.StatusOK, "application/x-go-sgf", []byte(g.ToSGF()))
 }
 
 type simplePoint struct {
@@ -134,7 +600,7 @@ func simplifyPoint(p game.Point) simplePoint {
 
 func simplifyBoard(b game.GameBoard) [][]simplePoint {
 	var simpleBoard [][]simplePoint
-	for _, row := range Game.Board.Points() {
+	for _, row := range b.Points() {
 		var simpleRow []simplePoint
 		for _, point := range row {
 			simpleRow = append(simpleRow, simplifyPoint(point))
@@ -145,21 +611,37 @@ func simplifyBoard(b game.GameBoard) [][]simplePoint {
 }
 
 type simpleGame struct {
-	Board  [][]simplePoint `json:"board"`
-	Score  map[string]int  `json:"score"`
-	Turn   string          `json:"turn"`
-	Passed bool            `json:"passed"`
-	Ended  bool            `json:"ended"`
-	Winner string          `json:"winner"`
+	ID       int             `json:"id"`
+	Board    [][]simplePoint `json:"board"`
+	Score    game.ScoreCount `json:"score"` // as /score counts it
+	Komi     float64         `json:"komi"`
+	Rules    string          `json:"rules"`
+	Level    string          `json:"level,omitempty"`
+	Turn     string          `json:"turn"`
+	Passed   bool            `json:"passed"`
+	Counting bool            `json:"counting"`
+	Dead     [][2]int        `json:"dead,omitempty"`
+	Accepted []string        `json:"accepted,omitempty"`
+	Ended    bool            `json:"ended"`
+	Winner   string          `json:"winner"`
+	Result   string          `json:"result"`
 }
 
 func simplifyGame(g game.Game) simpleGame {
 	return simpleGame{
-		Board:  simplifyBoard(g.Board),
-		Score:  g.Score,
-		Turn:   g.Turn,
-		Passed: g.Passed,
-		Ended:  g.Ended,
-		Winner: g.Winner,
+		ID:       g.ID,
+		Board:    simplifyBoard(g.Board),
+		Score:    g.Count(),
+		Komi:     g.Komi,
+		Rules:    g.Rules.Name,
+		Level:    g.Level,
+		Turn:     g.Turn,
+		Passed:   g.Passed,
+		Counting: g.Counting,
+		Dead:     g.Dead,
+		Accepted: g.Accepted,
+		Ended:    g.Ended,
+		Winner:   g.Winner,
+		Result:   g.Result,
 	}
 }
@@ -0,0 +1,92 @@
+package player
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"go-api/game"
+)
+
+// a move worth considering in an analysed position
+type Candidate struct {
+	Move  game.Move `json:"move"`
+	Score float64   `json:"score"` // evaluation from the point of view of the player to move
+	// principal variation: the line of play the search expects, starting with Move
+	PV []game.Move `json:"pv"`
+}
+
+// Analysis of a position: its best moves, best first
+type Analysis struct {
+	Turn       string      `json:"turn"`
+	Candidates []Candidate `json:"candidates"`
+	Depth      int         `json:"depth"` // depth of the deepest search completed
+	Nodes      int         `json:"nodes"`
+	Table      TableStats  `json:"table"`
+}
+
+// Analyze searches the position like SearchContext, but scores every move from it exactly
+// (rather than only showing the others are no better than the best) and returns the top n
+func Analyze(ctx context.Context, g game.Game, evaluator Evaluator, config SearchConfig, n int) Analysis {
+	s := &search{ctx: ctx, evaluator: evaluator, table: newTranspositionTable(config.TableSize)}
+	scores, depth := s.deepen(g, config, true)
+	analysis := Analysis{Turn: g.Turn, Candidates: []Candidate{}, Depth: depth, Nodes: s.nodes, Table: s.table.stats()}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	for _, sm := range scores {
+		analysis.Candidates = append(analysis.Candidates, Candidate{
+			Move:  moveFor(sm.move, g.Turn),
+			Score: finite(sm.score),
+			PV:    s.principalVariation(g, sm.move, depth),
+		})
+	}
+	return analysis
+}
+
+// JSON has no infinities, so scores beyond the largest float are clamped to it
+func finite(score float64) float64 {
+	if math.IsNaN(score) {
+		return 0
+	}
+	return math.Max(-math.MaxFloat64, math.Min(math.MaxFloat64, score))
+}
+
+func moveFor(p game.Point, color string) game.Move {
+	return game.Move{Color: color, X: p.X, Y: p.Y, Pass: p.X < 0}
+}
+
+// follow the best moves recorded in the transposition table from the position after move,
+// for at most depth moves in all
+func (s *search) principalVariation(g game.Game, move game.Point, depth int) []game.Move {
+	g = g.DeepCopy()
+	pv := []game.Move{}
+	for {
+		pv = append(pv, moveFor(move, g.Turn))
+		if move.X < 0 {
+			g.Pass()
+		} else {
+			g.PlayWithoutScoring(game.Point{X: move.X, Y: move.Y, Color: g.Turn})
+		}
+		if len(pv) >= depth || g.Over() || s.table == nil {
+			return pv
+		}
+		e, ok := s.table.lookup(tableKey(g))
+		if !ok || e.move == noMove {
+			return pv
+		}
+		size := g.Board.Size()
+		move = game.Point{X: -1, Y: -1}
+		if e.move != passMove {
+			move = game.Point{X: int(e.move) % size, Y: int(e.move) / size, Color: g.Turn}
+			// another position may have taken over the entry's slot with a colliding key
+			if !g.IsValidMove(move) {
+				return pv
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// named evaluator configs which can be chosen for a game or a single move
+// easy and hard differ from the default in how far ahead they look
+var Presets = map[string]EvalConfig{
+	"easy":   withSearch(DefaultConfig, 1e4, 2),
+	"medium": DefaultConfig,
+	"hard":   withSearch(DefaultConfig, 5e9, 12),
+}
+
+func withSearch(c EvalConfig, complexity int, eyeRecursion int) EvalConfig {
+	c.Complexity = complexity
+	c.EyeRecursion = eyeRecursion
+	return c
+}
+
+// names of every preset, in alphabetical order
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reject configs the evaluator can't work with
+func (c EvalConfig) Validate() error {
+	if c.Complexity < 1 {
+		return fmt.Errorf("complexity must be positive")
+	}
+	if c.EyeRecursion < 0 {
+		return fmt.Errorf("eye recursion must not be negative")
+	}
+	return nil
+}
+
+// parse a config from JSON, taking any weights it leaves out from DefaultConfig
+func ParseEvalConfig(data []byte) (EvalConfig, error) {
+	c := DefaultConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// read a file of named configs (a JSON object of name to config) and add them to Presets,
+// replacing any preset of the same name
+func LoadPresets(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	configs := map[string]EvalConfig{}
+	for name, raw := range file {
+		c, err := ParseEvalConfig(raw)
+		if err != nil {
+			return fmt.Errorf("%s: config %q: %v", path, name, err)
+		}
+		configs[name] = c
+	}
+	for name, c := range configs {
+		Presets[name] = c
+	}
+	return nil
+}
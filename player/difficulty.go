@@ -0,0 +1,76 @@
+package player
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"go-api/game"
+)
+
+// Level is a difficulty level for the computer, weakening it by limiting how far it
+// searches and by letting it play moves other than the best it found
+type Level struct {
+	Engine      string  `json:"engine"`             // "random", "minimax" or "mcts"
+	Config      string  `json:"config,omitempty"`   // preset evaluator config for minimax
+	Depth       int     `json:"depth,omitempty"`    // deepest minimax search (0 for no limit)
+	Playouts    int     `json:"playouts,omitempty"` // mcts playouts
+	Temperature float64 `json:"temperature,omitempty"`
+}
+
+// levels a game against the computer can be played at, from weakest to strongest
+// (temperatures are in evaluation points for minimax, see SearchConfig and MCTSConfig)
+var Levels = map[string]Level{
+	"random":   {Engine: "random"},
+	"beginner": {Engine: "mcts", Playouts: 100, Temperature: 1},
+	"easy":     {Engine: "minimax", Config: "easy", Depth: 1, Temperature: 30},
+	"medium":   {Engine: "minimax", Config: "medium", Depth: 2, Temperature: 10},
+	"hard":     {Engine: "minimax", Config: "hard", Depth: 3, Temperature: 2},
+	"max":      {Engine: "minimax", Config: "hard"},
+}
+
+// names of every level, in alphabetical order
+func LevelNames() []string {
+	names := make([]string, 0, len(Levels))
+	for name := range Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// choose a move for color as the computer playing at the given level
+// minimax searches stop when ctx is done, mcts searches at its deadline
+func LevelMove(ctx context.Context, g game.Game, color string, level Level) game.Point {
+	switch level.Engine {
+	case "random":
+		return RandomMove(g, color)
+	case "mcts":
+		config := DefaultMCTSConfig
+		config.Playouts = level.Playouts
+		config.Temperature = level.Temperature
+		if deadline, ok := ctx.Deadline(); ok {
+			// a time limit of 0 would mean no limit at all
+			config.TimeLimit = time.Until(deadline)
+			if config.TimeLimit <= 0 {
+				config.TimeLimit = time.Millisecond
+			}
+		}
+		move, err := MCTSMove(g, color, config)
+		if err != nil {
+			log.Printf("mcts level: %v", err)
+			return RandomMove(g, color)
+		}
+		return move
+	default:
+		evaluator, ok := Presets[level.Config]
+		if !ok {
+			evaluator = DefaultConfig
+		}
+		config := DefaultSearchConfig
+		config.MaxDepth = level.Depth
+		config.Temperature = level.Temperature
+		return SearchContext(ctx, g, color, evaluator, config).Move
+	}
+}
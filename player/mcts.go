@@ -0,0 +1,262 @@
+package player
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"go-api/game"
+)
+
+// playout policies
+const (
+	PolicyRandom = "random" // uniformly random moves (except filling your own eyes)
+	PolicyLight  = "light"  // capture stones in atari when possible, otherwise random
+)
+
+type MCTSConfig struct {
+	Playouts    int           // stop after this many playouts (0 for no limit)
+	TimeLimit   time.Duration // stop after thinking this long (0 for no limit)
+	Exploration float64       // UCT exploration constant
+	Policy      string        // how moves are chosen during playouts
+	// how freely to choose moves other than the most visited: each move is chosen with
+	// probability proportional to visits^(1/Temperature) (0 always plays the most visited)
+	Temperature float64
+}
+
+var DefaultMCTSConfig = MCTSConfig{
+	Playouts:    5000,
+	TimeLimit:   3 * time.Second,
+	Exploration: 1.4,
+	Policy:      PolicyLight,
+}
+
+// a node in the search tree: the position reached by playing move
+type mctsNode struct {
+	move     game.Point // move leading to this node (X, Y of -1 for a pass)
+	parent   *mctsNode
+	children []*mctsNode
+	untried  []game.Point // legal moves not yet expanded into children
+	visits   int
+	wins     float64 // playouts won by the player who made move (draws count half)
+}
+
+func newMCTSNode(g game.Game, move game.Point, parent *mctsNode) *mctsNode {
+	n := &mctsNode{move: move, parent: parent}
+	if !g.Over() {
+		n.untried = legalMoves(g)
+	}
+	return n
+}
+
+// every legal move for the player to move, plus passing
+func legalMoves(g game.Game) []game.Point {
+	moves := []game.Point{}
+	g.Board.ForEachPoint(func(p *game.Point) {
+		move := game.Point{X: p.X, Y: p.Y, Color: g.Turn}
+		if p.Color == "" && g.IsValidMove(move) {
+			moves = append(moves, move)
+		}
+	})
+	return append(moves, game.Point{X: -1, Y: -1, Color: g.Turn})
+}
+
+// upper confidence bound used to balance exploring and exploiting children
+func (n *mctsNode) uct(exploration float64) float64 {
+	if n.visits == 0 {
+		return math.Inf(1)
+	}
+	return n.wins/float64(n.visits) + exploration*math.Sqrt(math.Log(float64(n.parent.visits))/float64(n.visits))
+}
+
+func (n *mctsNode) bestChild(exploration float64) *mctsNode {
+	best := n.children[0]
+	bestScore := math.Inf(-1)
+	for _, child := range n.children {
+		if score := child.uct(exploration); score > bestScore {
+			best, bestScore = child, score
+		}
+	}
+	return best
+}
+
+func playMove(g *game.Game, p game.Point) {
+	if p.X < 0 {
+		g.Pass()
+	} else {
+		g.PlayWithoutScoring(p)
+	}
+}
+
+// does playing p fill in one of color's own eyes
+func fillsOwnEye(g game.Game, p *game.Point, color string) bool {
+	for _, adjP := range p.AdjPoints(g.Board) {
+		if adjP.Color != color {
+			return false
+		}
+	}
+	return true
+}
+
+// choose a move for the player to move during a playout
+func playoutMove(g game.Game, policy string, r *rand.Rand) game.Point {
+	color := g.Turn
+	if policy == PolicyLight {
+		// capture any enemy group in atari
+		for _, grp := range sortedGroups(g.Board) {
+			if grp.Color == color || grp.CountLiberties(g.Board) != 1 {
+				continue
+			}
+			for _, b := range grp.Bounds {
+				p := game.Point{X: b[0], Y: b[1], Color: color}
+				if g.Board.At(b[0], b[1]).Color == "" && g.IsValidMove(p) {
+					return p
+				}
+			}
+		}
+	}
+	size := g.Board.Size()
+	order := r.Perm(size * size)
+	for _, i := range order {
+		p := g.Board.At(i%size, i/size)
+		if p.Color != "" || fillsOwnEye(g, p, color) {
+			continue
+		}
+		move := game.Point{X: p.X, Y: p.Y, Color: color}
+		if g.IsValidMove(move) {
+			return move
+		}
+	}
+	return game.Point{X: -1, Y: -1, Color: color}
+}
+
+// play the game out until both players pass (or it runs far too long)
+// and report the winner, "" for a draw
+func playout(g game.Game, policy string, r *rand.Rand) string {
+	winner, _ := playOut(g, policy, r).CountResult()
+	return winner
+}
+
+// the position at the end of a playout
+func playOut(g game.Game, policy string, r *rand.Rand) game.Game {
+	limit := 3 * g.Board.Size() * g.Board.Size()
+	for moves := 0; !g.Over() && moves < limit; moves++ {
+		playMove(&g, playoutMove(g, policy, r))
+	}
+	return g
+}
+
+// reject configs which would never stop searching
+func (c MCTSConfig) Validate() error {
+	if c.Playouts < 0 || c.TimeLimit < 0 {
+		return fmt.Errorf("playouts and time limit must not be negative")
+	}
+	if c.Playouts == 0 && c.TimeLimit == 0 {
+		return fmt.Errorf("a playout or time limit is needed")
+	}
+	return nil
+}
+
+// MCTSMove chooses a move for color using Monte Carlo tree search (UCT)
+func MCTSMove(g game.Game, color string, config MCTSConfig) (game.Point, error) {
+	move, _, err := MCTSSearch(g, color, config)
+	return move, err
+}
+
+// choose a move using Monte Carlo tree search, also returning its estimated win rate
+func MCTSSearch(g game.Game, color string, config MCTSConfig) (game.Point, float64, error) {
+	if err := config.Validate(); err != nil {
+		return game.Point{}, 0, err
+	}
+	pass := game.Point{X: -1, Y: -1, Color: ""}
+	if g.Over() {
+		return pass, 0, nil
+	}
+	g = g.DeepCopy()
+	g.Turn = color
+	r := newRand()
+	root := newMCTSNode(g, pass, nil)
+	start := time.Now()
+
+	playouts := 0
+	for {
+		if config.Playouts > 0 && playouts >= config.Playouts {
+			break
+		}
+		if config.TimeLimit > 0 && time.Since(start) >= config.TimeLimit {
+			break
+		}
+		playouts++
+
+		// SELECTION
+		node := root
+		state := g.DeepCopy()
+		for len(node.untried) == 0 && len(node.children) > 0 {
+			node = node.bestChild(config.Exploration)
+			playMove(&state, node.move)
+		}
+
+		// EXPANSION
+		if len(node.untried) > 0 {
+			i := r.Intn(len(node.untried))
+			move := node.untried[i]
+			node.untried[i] = node.untried[len(node.untried)-1]
+			node.untried = node.untried[:len(node.untried)-1]
+			playMove(&state, move)
+			child := newMCTSNode(state, move, node)
+			node.children = append(node.children, child)
+			node = child
+		}
+
+		// SIMULATION
+		winner := playout(state.DeepCopy(), config.Policy, r)
+
+		// BACKPROPAGATION
+		for ; node != nil; node = node.parent {
+			node.visits++
+			switch winner {
+			case node.move.Color:
+				node.wins++
+			case "":
+				node.wins += 0.5
+			}
+		}
+	}
+
+	if len(root.children) == 0 {
+		return pass, 0, nil
+	}
+	// the most visited move is the most reliable
+	best := root.children[0]
+	for _, child := range root.children {
+		if child.visits > best.visits {
+			best = child
+		}
+	}
+	if config.Temperature > 0 {
+		best = sampleByVisits(root.children, config.Temperature, r)
+	}
+	winRate := best.wins / float64(best.visits)
+	log.Printf("MCTS Playouts: %v\nWin Rate: %.3f\n", playouts, winRate)
+	return game.Point{X: best.move.X, Y: best.move.Y, Color: color}, winRate, nil
+}
+
+// choose a child at random, favouring those visited most (see MCTSConfig.Temperature)
+func sampleByVisits(children []*mctsNode, temperature float64, r *rand.Rand) *mctsNode {
+	weights := make([]float64, len(children))
+	total := 0.0
+	for i, child := range children {
+		weights[i] = math.Pow(float64(child.visits), 1/temperature)
+		total += weights[i]
+	}
+	x := r.Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return children[i]
+		}
+		x -= w
+	}
+	return children[len(children)-1]
+}
@@ -0,0 +1,44 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"go-api/game"
+)
+
+func TestMCTSSearchLimits(t *testing.T) {
+	g, err := game.NewGame(game.Settings{Size: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		playouts  int
+		timeLimit time.Duration
+		ok        bool
+	}{
+		{"playouts", 10, 0, true},
+		{"time", 0, 10 * time.Millisecond, true},
+		{"both", 10, time.Second, true},
+		{"no limit", 0, 0, false},
+		{"negative playouts", -1, time.Second, false},
+		{"negative time", 10, -time.Second, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultMCTSConfig
+			config.Playouts, config.TimeLimit = test.playouts, test.timeLimit
+			move, _, err := MCTSSearch(g, "black", config)
+			if test.ok && err != nil {
+				t.Fatal(err)
+			}
+			if !test.ok && err == nil {
+				t.Fatal("searched without a limit")
+			}
+			if test.ok && move.X >= 0 && !g.IsValidMove(move) {
+				t.Errorf("got illegal move %+v", move)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	deep "github.com/patrikeh/go-deep"
+	"github.com/patrikeh/go-deep/training"
+
+	"go-api/game"
+)
+
+// feature planes describing a position, each with one input per point
+// all planes are from the point of view of the player to move
+const (
+	planeOwn = iota
+	planeOpponent
+	planeEmpty
+	planeOwnAtari // own stones in a group with one liberty
+	planeOwnTwoLiberties
+	planeOwnManyLiberties
+	planeOpponentAtari
+	planeOpponentTwoLiberties
+	planeOpponentManyLiberties
+	planeKo
+	planeBlackToMove // every input is 1 when black is to move
+	numPlanes
+)
+
+// Network is a small feed-forward policy/value network for one board size
+// the policy outputs a probability for every point (row by row) followed by passing,
+// the value estimates the probability that the player to move wins
+type Network struct {
+	size   int
+	policy *netPool
+	value  *netPool
+}
+
+// go-deep networks keep their activations while predicting, so they can't be shared between
+// goroutines: each prediction borrows a copy of the network, made from its weights as needed
+type netPool struct {
+	mu   sync.Mutex // restoring a dump initializes its config, so copies are made one at a time
+	dump *deep.Dump
+	nets sync.Pool
+}
+
+func newNetPool(n *deep.Neural) *netPool {
+	p := &netPool{dump: n.Dump()}
+	p.nets.New = func() interface{} {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return deep.FromDump(p.dump)
+	}
+	p.nets.Put(n)
+	return p
+}
+
+func (p *netPool) predict(features []float64) []float64 {
+	n := p.nets.Get().(*deep.Neural)
+	defer p.nets.Put(n)
+	return n.Predict(features)
+}
+
+// create a network with random weights and the given hidden layer sizes
+func NewNetwork(size int, hidden ...int) *Network {
+	points := size * size
+	inputs := numPlanes * points
+	policyLayout := append(append([]int{}, hidden...), points+1)
+	valueLayout := append(append([]int{}, hidden...), 1)
+	return &Network{
+		size: size,
+		policy: newNetPool(deep.NewNeural(&deep.Config{
+			Inputs:     inputs,
+			Layout:     policyLayout,
+			Activation: deep.ActivationReLU,
+			Mode:       deep.ModeMultiClass,
+			Weight:     deep.NewNormal(0.1, 0),
+			Bias:       true,
+		})),
+		value: newNetPool(deep.NewNeural(&deep.Config{
+			Inputs:     inputs,
+			Layout:     valueLayout,
+			Activation: deep.ActivationReLU,
+			Mode:       deep.ModeBinary,
+			Weight:     deep.NewNormal(0.1, 0),
+			Bias:       true,
+		})),
+	}
+}
+
+func (n *Network) Size() int {
+	return n.size
+}
+
+// weights file format
+type networkFile struct {
+	Size   int        `json:"size"`
+	Policy *deep.Dump `json:"policy"`
+	Value  *deep.Dump `json:"value"`
+}
+
+// load a network saved by Save
+func LoadNetwork(path string) (*Network, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file networkFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if file.Policy == nil || file.Value == nil {
+		return nil, fmt.Errorf("%s: missing policy or value network", path)
+	}
+	inputs := numPlanes * file.Size * file.Size
+	if file.Policy.Config.Inputs != inputs || file.Value.Config.Inputs != inputs {
+		return nil, fmt.Errorf("%s: network inputs do not match a %dx%d board", path, file.Size, file.Size)
+	}
+	return &Network{
+		size:   file.Size,
+		policy: newNetPool(deep.FromDump(file.Policy)),
+		value:  newNetPool(deep.FromDump(file.Value)),
+	}, nil
+}
+
+// write the network's configuration and weights to a file
+func (n *Network) Save(path string) error {
+	file := networkFile{Size: n.size, Policy: n.policy.dump, Value: n.value.dump}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// a position from a game and what came of it, for the network to learn from
+type TrainingExample struct {
+	Position game.Game  // with the player who moved to move
+	Move     game.Point // the move played (X and Y of -1 for a pass)
+	Result   float64    // 1 if the player to move went on to win, 0 if they lost and 0.5 for a draw
+}
+
+// Train fits the policy to the moves played and the value to the results, going over the
+// examples the given number of times with stochastic gradient descent at the given learning rate
+// the network must not be used while it is being trained
+func (n *Network) Train(examples []TrainingExample, epochs int, rate float64) error {
+	points := n.size * n.size
+	policyExamples := make(training.Examples, 0, len(examples))
+	valueExamples := make(training.Examples, 0, len(examples))
+	for _, e := range examples {
+		if e.Position.Board.Size() != n.size {
+			return fmt.Errorf("example is on a %dx%d board, not %dx%d",
+				e.Position.Board.Size(), e.Position.Board.Size(), n.size, n.size)
+		}
+		features := Features(e.Position)
+		move := make([]float64, points+1)
+		move[moveIndex(e.Move, n.size)] = 1
+		policyExamples = append(policyExamples, training.Example{Input: features, Response: move})
+		valueExamples = append(valueExamples, training.Example{Input: features, Response: []float64{e.Result}})
+	}
+	policy, value := deep.FromDump(n.policy.dump), deep.FromDump(n.value.dump)
+	training.NewTrainer(training.NewSGD(rate, 0.9, 0, false), 0).Train(policy, policyExamples, nil, epochs)
+	training.NewTrainer(training.NewSGD(rate, 0.9, 0, false), 0).Train(value, valueExamples, nil, epochs)
+	n.policy, n.value = newNetPool(policy), newNetPool(value)
+	return nil
+}
+
+// the policy output for a move
+func moveIndex(p game.Point, size int) int {
+	if p.X < 0 {
+		return size * size
+	}
+	return p.Y*size + p.X
+}
+
+// Features encodes a position as network inputs (see the plane constants)
+func Features(g game.Game) []float64 {
+	size := g.Board.Size()
+	points := size * size
+	features := make([]float64, numPlanes*points)
+	set := func(plane int, p game.Point) {
+		features[plane*points+p.Y*size+p.X] = 1
+	}
+	toMove := g.Turn
+	if toMove == "" {
+		toMove = "black"
+	}
+	g.Board.ForEachPoint(func(p *game.Point) {
+		if g.Turn == "black" {
+			set(planeBlackToMove, *p)
+		}
+		if p.Color == "" {
+			set(planeEmpty, *p)
+			return
+		}
+		liberties := g.Board.Groups[p.GroupId].CountLiberties(g.Board)
+		libertyPlane := 0
+		switch {
+		case liberties <= 1:
+			libertyPlane = planeOwnAtari
+		case liberties == 2:
+			libertyPlane = planeOwnTwoLiberties
+		default:
+			libertyPlane = planeOwnManyLiberties
+		}
+		if p.Color == toMove {
+			set(planeOwn, *p)
+		} else {
+			set(planeOpponent, *p)
+			libertyPlane += planeOpponentAtari - planeOwnAtari
+		}
+		set(libertyPlane, *p)
+	})
+	if g.Ko[0] >= 0 {
+		set(planeKo, game.Point{X: g.Ko[0], Y: g.Ko[1]})
+	}
+	return features
+}
+
+// move probabilities (row by row, then passing) and the chance the player to move wins
+func (n *Network) Predict(g game.Game) (policy []float64, value float64) {
+	features := Features(g)
+	return n.policy.predict(features), n.value.predict(features)[0]
+}
+
+// put moves in order of the policy's probability for them, most likely first
+func (n *Network) orderMoves(g game.Game, moves []game.Point) {
+	policy := n.policy.predict(Features(g))
+	sort.SliceStable(moves, func(i, j int) bool {
+		return policy[moveIndex(moves[i], n.size)] > policy[moveIndex(moves[j], n.size)]
+	})
+}
+
+// Evaluate implements Evaluator using the value network
+// scores range from -1 (color is sure to lose) to 1 (color is sure to win)
+func (n *Network) Evaluate(g game.Game, color string) float64 {
+	value := n.value.predict(Features(g))[0]
+	if g.Turn != color {
+		value = 1 - value
+	}
+	return 2*value - 1
+}
@@ -1,10 +1,14 @@
 package player
 
 import (
-	"fmt"
+	"context"
 	"go-api/game"
+	"log"
 	"math"
 	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -52,39 +56,79 @@ func proximityScan(c scanContext) (scannedPoints []game.Point, isAnEye bool, con
 	return c.scanned, true, c.connectionDepth
 }
 
+// EvalConfig holds the weights of the static evaluator (and how far the minimax search may look ahead)
 type EvalConfig struct {
-	complexity      int
-	eyeRecursion    int
-	eyeWeight       float64
-	libertyWeight   float64
-	areaWeight      float64
-	sizeWeight      float64
-	captureWeight   float64
-	koWeight        float64
-	densityWeight   float64
-	connDepthWeight float64
-	groupAvgWeight  float64
+	Complexity      int     `json:"complexity"`    // the number of lines a search may consider, which limits its depth
+	EyeRecursion    int     `json:"eye_recursion"` // how far to scan from a liberty looking for an eye
+	EyeWeight       float64 `json:"eye_weight"`
+	LibertyWeight   float64 `json:"liberty_weight"`
+	AreaWeight      float64 `json:"area_weight"`
+	SizeWeight      float64 `json:"size_weight"`
+	CaptureWeight   float64 `json:"capture_weight"`
+	KoWeight        float64 `json:"ko_weight"`
+	DensityWeight   float64 `json:"density_weight"`
+	ConnDepthWeight float64 `json:"conn_depth_weight"`
+	GroupAvgWeight  float64 `json:"group_avg_weight"`
 }
 
 var DefaultConfig = EvalConfig{
-	complexity:      5e7,
-	eyeRecursion:    8,
-	eyeWeight:       .75,
-	libertyWeight:   .5,
-	areaWeight:      .33,
-	sizeWeight:      .05,
-	captureWeight:   .65,
-	koWeight:        .3,
-	densityWeight:   .45,
-	connDepthWeight: .7,
-	groupAvgWeight:  .05,
+	Complexity:      5e7,
+	EyeRecursion:    8,
+	EyeWeight:       .75,
+	LibertyWeight:   .5,
+	AreaWeight:      .33,
+	SizeWeight:      .05,
+	CaptureWeight:   .65,
+	KoWeight:        .3,
+	DensityWeight:   .45,
+	ConnDepthWeight: .7,
+	GroupAvgWeight:  .05,
+}
+
+// the board's groups in a consistent order (map iteration order is random),
+// so that searches seeded alike give identical results
+// groups are ordered by their first stone, not their IDs, which depend on the order the stones were played
+func sortedGroups(b game.GameBoard) []*game.Group {
+	groups := make([]*game.Group, 0, len(b.Groups))
+	first := map[*game.Group]int{}
+	for _, grp := range b.Groups {
+		groups = append(groups, grp)
+		first[grp] = b.Size() * b.Size()
+		for _, p := range grp.Points {
+			if i := p.Y*b.Size() + p.X; i < first[grp] {
+				first[grp] = i
+			}
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return first[groups[i]] < first[groups[j]] })
+	return groups
+}
+
+// a group's bounds (the empty or enemy points next to it) in board order,
+// rather than the order the group happened to grow in
+func sortedBounds(grp *game.Group) [][2]int {
+	bounds := append([][2]int{}, grp.Bounds...)
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i][1] < bounds[j][1] || (bounds[i][1] == bounds[j][1] && bounds[i][0] < bounds[j][0])
+	})
+	return bounds
+}
+
+// an Evaluator scores a position from color's point of view (higher is better)
+type Evaluator interface {
+	Evaluate(g game.Game, color string) float64
+}
+
+// Evaluate implements Evaluator using the hand-tuned static evaluation
+func (config EvalConfig) Evaluate(g game.Game, color string) float64 {
+	return staticEvalByGroup(g, color, config)
 }
 
 func staticEvalByGroup(g game.Game, color string, config EvalConfig) float64 {
 	score := map[string]float64{"black": 0, "white": 0}
 	groupCount := map[string]int{"black": 0, "white": 0}
 
-	for _, grp := range g.Board.Groups {
+	for _, grp := range sortedGroups(g.Board) {
 		groupCount[grp.Color]++
 
 		numEyes := 0
@@ -97,7 +141,7 @@ func staticEvalByGroup(g game.Game, color string, config EvalConfig) float64 {
 		ScannedPoints := []game.Point{}
 		ConnectionDepth := math.Inf(-1)
 
-		for _, b := range grp.Bounds {
+		for _, b := range sortedBounds(grp) {
 			bPoint := *g.Board.At(b[0], b[1])
 
 			xMax = math.Max(float64(b[0]), xMax)
@@ -120,7 +164,7 @@ func staticEvalByGroup(g game.Game, color string, config EvalConfig) float64 {
 						point:           bPoint,
 						group:           *grp,
 						scanned:         ScannedPoints,
-						depth:           config.eyeRecursion,
+						depth:           config.EyeRecursion,
 						connectionDepth: ConnectionDepth,
 					})
 					ScannedPoints, Eye, ConnectionDepth = scannedPoints, eye, math.Max(ConnectionDepth, connectionDepth)
@@ -133,33 +177,33 @@ func staticEvalByGroup(g game.Game, color string, config EvalConfig) float64 {
 			//// DIMENSIONS OF GROUP
 			// AREA
 			area := (xMax - xMin) * (yMax - yMin)
-			score[grp.Color] += area * 0.5 * config.areaWeight // area weighted lower than liberties
+			score[grp.Color] += area * 0.5 * config.AreaWeight // area weighted lower than liberties
 
 			// SIZE
 			size := float64(grp.Size())
-			score[grp.Color] += size * config.sizeWeight // size weighted lower than area
+			score[grp.Color] += size * config.SizeWeight // size weighted lower than area
 
 			// DENSITY
 			if area > 0 {
-				score[grp.Color] += (size / area) * 100 * config.densityWeight
+				score[grp.Color] += (size / area) * 100 * config.DensityWeight
 			}
 
 			//// SUSCEPTIBILITY TO CAPTURE
 			// NUMBER OF EYES
 			if numEyes > 1 {
-				score[grp.Color] += area * 0.5 * config.eyeWeight // two eyes are better than one
+				score[grp.Color] += area * 0.5 * config.EyeWeight // two eyes are better than one
 			} else if numEyes == 1 {
-				score[grp.Color] += area * 0.2 * config.eyeWeight
+				score[grp.Color] += area * 0.2 * config.EyeWeight
 			}
 
 			// NUMBER OF LIBERTIES
 			if numEyes < 2 {
-				score[grp.Color] += float64(numLiberties) * 0.5 * config.libertyWeight
+				score[grp.Color] += float64(numLiberties) * 0.5 * config.LibertyWeight
 			}
 
 			// PROXIMITY TO FRIENDLY GROUPS (CONNECTION DEPTH)
 			if !math.IsInf(ConnectionDepth, -1) {
-				score[grp.Color] += ConnectionDepth * area * 0.1 * config.connDepthWeight
+				score[grp.Color] += ConnectionDepth * area * 0.1 * config.ConnDepthWeight
 			}
 
 		}
@@ -169,8 +213,8 @@ func staticEvalByGroup(g game.Game, color string, config EvalConfig) float64 {
 
 	// AVERAGE GROUP VALUE
 	if groupCount["white"] > 0 && groupCount["black"] > 0 {
-		score[color] += (score[color] / float64(groupCount[color])) * 0.2 * config.groupAvgWeight
-		score[oppColor] += (score[oppColor] / float64(groupCount[oppColor])) * 0.2 * config.groupAvgWeight
+		score[color] += (score[color] / float64(groupCount[color])) * 0.2 * config.GroupAvgWeight
+		score[oppColor] += (score[oppColor] / float64(groupCount[oppColor])) * 0.2 * config.GroupAvgWeight
 	}
 
 	// RESULTING CAPTURES
@@ -181,43 +225,123 @@ func staticEvalByGroup(g game.Game, color string, config EvalConfig) float64 {
 	// DOES MOVE START A KO FIGHT?
 	var koScore float64 = 0
 	if g.Ko != [2]int{-1, -1} {
-		koScore = -0.1 * (score["white"] + score["black"]) * config.koWeight
+		koScore = -0.1 * (score["white"] + score["black"]) * config.KoWeight
 	}
 
-	return score[color] - score[oppColor] + captureScore*config.captureWeight + koScore
+	return score[color] - score[oppColor] + captureScore*config.CaptureWeight + koScore
+}
+
+// state shared by every node of a search
+type search struct {
+	ctx       context.Context
+	evaluator Evaluator
+	table     *transpositionTable
+	nodes     int
+	aborted   bool // ctx was done before the search finished, so its results are incomplete
 }
 
 // Recursively evaluate possible moves and counter-moves using minimax algorithm
 // returns eval score and slice of moves which result in that score
-func minimax(g game.Game, depth int, alpha float64, beta float64, maximize bool, noPass bool) (float64, []game.Point) {
-	if depth == 0 || g.Ended {
+func (s *search) minimax(g game.Game, depth int, alpha float64, beta float64, maximize bool, noPass bool) (float64, []game.Point) {
+	// checking the context is relatively expensive, so only do it every so often
+	s.nodes++
+	if s.nodes%64 == 0 && s.ctx.Err() != nil {
+		s.aborted = true
+	}
+	if s.aborted {
+		return 0, []game.Point{}
+	}
+
+	if depth == 0 || g.Over() {
 		var eval float64
 		if maximize {
-			eval = staticEvalByGroup(g, g.Turn, DefaultConfig)
+			eval = s.evaluator.Evaluate(g, g.Turn)
 		} else {
-			eval = staticEvalByGroup(g, game.OppositeColor(g.Turn), DefaultConfig)
+			eval = s.evaluator.Evaluate(g, game.OppositeColor(g.Turn))
 		}
 		return float64(eval), []game.Point{}
 	}
 
-	// Evaluate resulting game state for a given point
-	// Returns result state, true for a valid move, or current state, false for invalid move
-	testPoint := func(p *game.Point) (game.Game, bool) {
+	// a position searched before (to at least this depth) may not need searching again,
+	// otherwise its best move is tried first, since it's likely to still be good
+	size := g.Board.Size()
+	key := tableKey(g)
+	best := int16(noMove)
+	if e, ok := s.table.probe(key); ok {
+		best = e.move
+		if int(e.depth) >= depth && (e.bound == boundExact ||
+			(e.bound == boundLower && e.score >= beta) ||
+			(e.bound == boundUpper && e.score <= alpha)) {
+			s.table.cut()
+			moves := []game.Point{}
+			if e.move == passMove {
+				moves = append(moves, game.Point{X: -1, Y: -1, Color: ""})
+			} else if e.move >= 0 {
+				moves = append(moves, *g.Board.At(int(e.move)%size, int(e.move)/size))
+			}
+			return e.score, moves
+		}
+	}
+	var first *game.Point
+	if best >= 0 {
+		first = g.Board.At(int(best)%size, int(best)/size)
+	}
+	alphaOrig, betaOrig := alpha, beta
+
+	// play the move at a point (if it is legal), call search on the resulting position, then
+	// take the move back, so that children don't each need a copy of the game
+	// returns whether the move was legal
+	testPoint := func(p *game.Point, search func(p *game.Point)) bool {
 		testPoint := game.Point{X: p.X, Y: p.Y, Color: g.Turn}
-		if g.IsValidMove(testPoint) {
-			testGame := g.DeepCopy()
-			testGame.PlayWithoutScoring(testPoint)
-			return testGame, true
-		} else {
-			return g, false
+		if !g.IsValidMove(testPoint) {
+			return false
+		}
+		move := *p
+		g.PlayWithoutScoring(testPoint)
+		search(&move)
+		g.Undo()
+		return true
+	}
+
+	// call try for the best move from the table, then every other point in a random order
+	// until it returns true, returning whether every point was tried
+	forEachMove := func(try func(p *game.Point) bool) bool {
+		if first != nil && try(first) {
+			return false
+		}
+		for _, i := range newRand().Perm(size * size) {
+			p := g.Board.At(i%size, i/size)
+			if p == first {
+				continue
+			}
+			if try(p) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// remember what was learned about this position
+	// its score is exact unless some moves weren't searched or were cut off by the window
+	store := func(score float64, moves []game.Point, bound int8) {
+		if s.aborted {
+			return
+		}
+		e := tableEntry{key: key, score: score, depth: int16(depth), bound: bound, move: noMove}
+		if len(moves) > 0 {
+			e.move = encodeMove(moves[0], size)
 		}
+		s.table.store(e)
 	}
 
 	if maximize {
 		maxEval := math.Inf(-1)
 		moves := []game.Point{}
-		evaluate := func(testGame game.Game, p *game.Point) {
-			eval, _ := minimax(testGame, depth-1, alpha, beta, false, noPass)
+		evaluate := func(p *game.Point) {
+			eval, _ := s.minimax(g, depth-1, alpha, beta, false, noPass)
+			if s.aborted {
+				return
+			}
 			if eval > maxEval {
 				moves = []game.Point{*p}
 				maxEval = eval
@@ -229,25 +353,25 @@ func minimax(g game.Game, depth int, alpha float64, beta float64, maximize bool,
 		}
 
 		if !noPass {
-			testPass := g.DeepCopy()
-			testPass.Pass()
-			evaluate(testPass, &game.Point{X: -1, Y: -1, Color: ""})
+			g.Pass()
+			evaluate(&game.Point{X: -1, Y: -1, Color: ""})
+			g.Undo()
 		}
 
-		rng := NewUniqueRand(g.Board.Size())
-		for {
-			coord := rng.Coord()
-			if coord[0] < 0 {
-				break
-			}
-			p := g.Board.At(coord[0], coord[1])
-
-			if testGame, ok := testPoint(p); ok {
-				evaluate(testGame, p)
-				if beta <= alpha {
-					break
-				}
-			}
+		complete := forEachMove(func(p *game.Point) bool {
+			return testPoint(p, evaluate) && (beta <= alpha || s.aborted)
+		})
+		if len(moves) == 0 && !s.aborted {
+			// no legal move (and passing isn't considered), so the position stands as it is
+			return s.evaluator.Evaluate(g, g.Turn), moves
+		}
+		switch {
+		case maxEval >= betaOrig || !complete:
+			store(maxEval, moves, boundLower)
+		case maxEval <= alphaOrig:
+			store(maxEval, moves, boundUpper)
+		default:
+			store(maxEval, moves, boundExact)
 		}
 		return maxEval, moves
 
@@ -255,39 +379,62 @@ func minimax(g game.Game, depth int, alpha float64, beta float64, maximize bool,
 		minEval := math.Inf(1)
 		moves := []game.Point{}
 
-		evaluate := func(testGame game.Game, p *game.Point) float64 {
-			eval, _ := minimax(testGame, depth-1, alpha, beta, true, noPass)
+		evaluate := func(p *game.Point) {
+			eval, _ := s.minimax(g, depth-1, alpha, beta, true, noPass)
+			if s.aborted {
+				return
+			}
 			if eval < minEval {
 				moves = []game.Point{*p}
 				minEval = eval
 			} else if eval == minEval {
 				moves = append(moves, *p)
 			}
-			return eval
+			beta = math.Min(beta, eval)
 		}
-		rng := NewUniqueRand(g.Board.Size())
 
-		for {
-			coord := rng.Coord()
-			if coord[0] < 0 {
-				break
-			}
-			p := g.Board.At(coord[0], coord[1])
-			if testGame, ok := testPoint(p); ok {
-				eval := evaluate(testGame, p)
-				beta = math.Min(alpha, eval)
-				if beta <= alpha {
-					break
-				}
-			}
+		complete := forEachMove(func(p *game.Point) bool {
+			return testPoint(p, evaluate) && (beta <= alpha || s.aborted)
+		})
+		if len(moves) == 0 && !s.aborted {
+			// no legal move (the minimizing player doesn't consider passing), so the position stands as it is
+			return s.evaluator.Evaluate(g, game.OppositeColor(g.Turn)), moves
+		}
+		switch {
+		case minEval <= alphaOrig || !complete:
+			store(minEval, moves, boundUpper)
+		case minEval >= betaOrig:
+			store(minEval, moves, boundLower)
+		default:
+			store(minEval, moves, boundExact)
 		}
 		return minEval, moves
 	}
 }
 
+// every random choice made by a player is drawn from this generator
+// so that seeding it makes games reproducible
+var (
+	rngMu sync.Mutex
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// reseed the players' random choices (e.g. to reproduce a series of games)
+func Seed(seed int64) {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	rng = rand.New(rand.NewSource(seed))
+}
+
+// a generator for use by a single goroutine, seeded from the shared one
+func newRand() *rand.Rand {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	return rand.New(rand.NewSource(rng.Int63()))
+}
+
 func RandomMove(g game.Game, color string) game.Point {
-	s1 := rand.NewSource(time.Now().UnixNano())
-	r1 := rand.New(s1)
+	r1 := newRand()
 	tries := 0
 	for tries < 99 {
 		tries++
@@ -303,46 +450,9 @@ func RandomMove(g game.Game, color string) game.Point {
 	return game.Point{X: -1, Y: -1, Color: ""}
 }
 
-type UniqueRand struct {
-	generated map[[2]int]bool //keeps track of
-	rng       *rand.Rand      //underlying random number generator
-	scope     int             //scope of number to be generated
-}
-
-func NewUniqueRand(size int) *UniqueRand {
-	s1 := rand.NewSource(time.Now().UnixNano())
-	r1 := rand.New(s1)
-	return &UniqueRand{
-		generated: make(map[[2]int]bool),
-		rng:       r1,
-		scope:     size,
-	}
-}
-
-func (u *UniqueRand) Coord() [2]int {
-	if u.scope > 0 && len(u.generated) >= u.scope*u.scope+1 {
-		return [2]int{-1, -1}
-	}
-	ind := [][2]int{}
-	for x := 0; x < u.scope; x++ {
-		for y := 0; y < u.scope; y++ {
-			ind = append(ind, [2]int{x, y})
-		}
-	}
-	ind = append(ind, [2]int{-1, -1})
-	for {
-		c := ind[u.rng.Int()%len(ind)]
-		if !u.generated[c] {
-			u.generated[c] = true
-			return c
-		}
-	}
-}
-
 // pick a random move from list of moves
 func SelectMove(color string, moves []game.Point) game.Point {
-	s := rand.NewSource(time.Now().UnixNano())
-	r := rand.New(s)
+	r := newRand()
 	n := r.Intn(len(moves))
 	return game.Point{
 		X:     moves[n].X,
@@ -351,14 +461,16 @@ func SelectMove(color string, moves []game.Point) game.Point {
 	}
 }
 
-// find the max depth for which, given the number of pieces on the board (coverage)
+// find the max depth for which, given the number of points on the board
+// and the number of pieces on the board (coverage)
 // would yield fewer options than maxComplexity
-func maximumDepth(coverage int, maxComplexity int) int {
+func maximumDepth(points int, coverage int, maxComplexity int) int {
 	depth := 1
-	options := 81 - coverage
+	options := points - coverage
 	for {
-		next := options * (81 - coverage - depth)
-		if next >= maxComplexity {
+		next := options * (points - coverage - depth)
+		// stop before searching deeper than the number of open points
+		if next >= maxComplexity || depth >= points-coverage {
 			break
 		}
 		depth++
@@ -368,25 +480,275 @@ func maximumDepth(coverage int, maxComplexity int) int {
 }
 
 func Move(g game.Game, color string) game.Point {
-	p := game.Point{X: -1, Y: -1, Color: ""}
+	return MoveWithEvaluator(g, color, DefaultConfig)
+}
+
+// choose a move using minimax search, scoring positions with the given evaluator
+func MoveWithEvaluator(g game.Game, color string, evaluator Evaluator) game.Point {
+	move, _ := Search(g, color, evaluator)
+	return move
+}
+
+// choose a move using minimax search for at most DefaultSearchTime,
+// also returning the evaluation of the resulting line
+func Search(g game.Game, color string, evaluator Evaluator) (game.Point, float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSearchTime)
+	defer cancel()
+	result := SearchContext(ctx, g, color, evaluator, DefaultSearchConfig)
+	return result.Move, result.Score
+}
+
+// every move to search from the root position: the best moves found by
+// the previous iteration first, then the rest in a random order
+func rootMoves(g game.Game, noPass bool, best []game.Point) []game.Point {
+	moves := []game.Point{}
+	seen := map[[2]int]bool{}
+	add := func(p game.Point) {
+		if !seen[[2]int{p.X, p.Y}] {
+			seen[[2]int{p.X, p.Y}] = true
+			moves = append(moves, game.Point{X: p.X, Y: p.Y, Color: g.Turn})
+		}
+	}
+	for _, p := range best {
+		add(p)
+	}
+	if !noPass {
+		add(game.Point{X: -1, Y: -1})
+	}
+	size := g.Board.Size()
+	for _, i := range newRand().Perm(size * size) {
+		p := game.Point{X: i % size, Y: i / size, Color: g.Turn}
+		if g.IsValidMove(p) {
+			add(p)
+		}
+	}
+	return moves
+}
+
+// a move from the root position and its score
+type scoredMove struct {
+	move  game.Point
+	score float64
+}
+
+// search each of the root position's moves to the given depth, sharing them out between workers
+// unless every score must be exact, the best score so far is shared too, so that
+// each move only has to be searched far enough to show it is no better
+// returns the score of every move searched before the search was aborted, in no particular order
+func (s *search) root(g game.Game, depth int, moves []game.Point, workers int, noPass bool, exact bool) []scoredMove {
+	if workers < 1 {
+		workers = 1
+	}
+	var mu sync.Mutex // guards scores and alpha
+	alpha := math.Inf(-1)
+	scores := []scoredMove{}
+
+	next := make(chan game.Point)
+	var wg sync.WaitGroup
+	searches := make([]search, workers)
+	for i := range searches {
+		// each worker keeps its own node count and abort flag
+		w := &searches[i]
+		*w = *s
+		w.nodes = 0
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range next {
+				testGame := g.DeepCopy()
+				if p.X < 0 {
+					testGame.Pass()
+				} else {
+					testGame.PlayWithoutScoring(p)
+				}
+				a := math.Inf(-1)
+				if !exact {
+					mu.Lock()
+					a = alpha
+					mu.Unlock()
+				}
+				eval, _ := w.minimax(testGame, depth-1, a, math.Inf(1), false, noPass)
+				if w.aborted {
+					continue
+				}
+				mu.Lock()
+				scores = append(scores, scoredMove{move: p, score: eval})
+				alpha = math.Max(alpha, eval)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range moves {
+		if s.ctx.Err() != nil {
+			break
+		}
+		next <- p
+	}
+	close(next)
+	wg.Wait()
+
+	for _, w := range searches {
+		s.nodes += w.nodes
+		s.aborted = s.aborted || w.aborted
+	}
+	if s.ctx.Err() != nil {
+		s.aborted = true
+	}
+	return scores
+}
+
+// the best score and every move which achieves it
+func bestScored(scores []scoredMove) (float64, []game.Point) {
+	best := math.Inf(-1)
+	moves := []game.Point{}
+	for _, sm := range scores {
+		if sm.score > best {
+			best, moves = sm.score, []game.Point{sm.move}
+		} else if sm.score == best {
+			moves = append(moves, sm.move)
+		}
+	}
+	return best, moves
+}
+
+// how long a search is usually given to choose a move
+const DefaultSearchTime = 10 * time.Second
+
+// SearchConfig controls the resources a minimax search may use and how it chooses a move
+type SearchConfig struct {
+	TableSize int // megabytes of memory for the transposition table (0 for none)
+	Workers   int // goroutines searching moves from the root position at once
+	MaxDepth  int // deepest search (0 for as deep as the evaluator's complexity allows)
+	// how freely to choose moves scoring worse than the best: a move scoring d less
+	// is e^(-d/Temperature) times as likely to be played (0 always plays a best move)
+	Temperature float64
+}
+
+var DefaultSearchConfig = SearchConfig{
+	TableSize: 16,
+	Workers:   runtime.NumCPU(),
+}
+
+// the outcome of a search
+type SearchResult struct {
+	Move  game.Point
+	Score float64
+	Depth int        // depth of the deepest search completed
+	Nodes int        // number of positions visited
+	Table TableStats // how often positions were found in the transposition table
+}
+
+// SearchContext searches one level deeper at a time (iterative deepening) until the
+// maximum depth is reached or ctx is done, returning the best move found so far
+func SearchContext(ctx context.Context, g game.Game, color string, evaluator Evaluator, config SearchConfig) SearchResult {
+	s := &search{ctx: ctx, evaluator: evaluator, table: newTranspositionTable(config.TableSize)}
+	// a weaker move can only be chosen fairly if its score is known exactly
+	scores, depth := s.deepen(g, config, config.Temperature > 0)
+	result := SearchResult{Move: game.Point{X: -1, Y: -1, Color: ""}, Depth: depth, Nodes: s.nodes, Table: s.table.stats()}
+	var bestMoves []game.Point
+	result.Score, bestMoves = bestScored(scores)
+	if len(scores) == 0 {
+		// there was no move to search, so the computer passes and the position stands as it is
+		result.Score = evaluator.Evaluate(g, color)
+	}
+	log.Printf("Eval Score: %v\nDepth: %v\nNodes: %v\nNum Equiv Moves: %v\n", result.Score, result.Depth, result.Nodes, len(bestMoves))
+	log.Printf("Table Hit Rate: %.3f (%v probes, %v cutoffs)\n", result.Table.HitRate, result.Table.Probes, result.Table.Cutoffs)
+
+	if config.Temperature > 0 && len(scores) > 0 {
+		chosen := softmaxChoice(scores, config.Temperature)
+		result.Move, result.Score = game.Point{X: chosen.move.X, Y: chosen.move.Y, Color: color}, chosen.score
+	} else if len(bestMoves) > 0 {
+		result.Move = SelectMove(color, bestMoves)
+	}
+	return result
+}
+
+// the first legal stone played from the root moves (a pass only if there isn't one),
+// scored by the evaluator without searching
+func (s *search) fallback(g game.Game, moves []game.Point) []scoredMove {
+	for _, m := range moves {
+		if m.X >= 0 {
+			after := g.DeepCopy()
+			after.Play(m)
+			return []scoredMove{{move: m, score: s.evaluator.Evaluate(after, g.Turn)}}
+		}
+	}
+	if len(moves) > 0 {
+		return []scoredMove{{move: moves[0], score: s.evaluator.Evaluate(g, g.Turn)}}
+	}
+	return nil
+}
+
+// choose a move at random, favouring higher scores (see SearchConfig.Temperature)
+func softmaxChoice(scores []scoredMove, temperature float64) scoredMove {
+	best, _ := bestScored(scores)
+	if math.IsInf(best, -1) {
+		// every move loses, so it makes no difference
+		return scores[newRand().Intn(len(scores))]
+	}
+	weights := make([]float64, len(scores))
+	total := 0.0
+	for i, sm := range scores {
+		weights[i] = math.Exp((sm.score - best) / temperature)
+		total += weights[i]
+	}
+	x := newRand().Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return scores[i]
+		}
+		x -= w
+	}
+	return scores[len(scores)-1]
+}
+
+// search the root position one level deeper at a time until the maximum depth
+// is reached or the search is aborted, returning the scores of the root moves
+// from the deepest search completed and its depth
+// if no search completes, returns the scores of the moves it did finish with depth 0
+func (s *search) deepen(g game.Game, config SearchConfig, exact bool) ([]scoredMove, int) {
 	coverage := -g.Captures["white"] - g.Captures["black"]
 	for _, grp := range g.Board.Groups {
 		coverage += grp.Size()
 	}
 
-	depth := maximumDepth(coverage, DefaultConfig.complexity)
+	points := g.Board.Size() * g.Board.Size()
+	complexity := DefaultConfig.Complexity
+	if c, ok := s.evaluator.(EvalConfig); ok {
+		complexity = c.Complexity
+	}
+	maxDepth := maximumDepth(points, coverage, complexity)
+	if config.MaxDepth > 0 && maxDepth > config.MaxDepth {
+		maxDepth = config.MaxDepth
+	}
 
-	fmt.Printf("Coverage: %v\nPossible Moves: %v\nDepth: %v\n", coverage, 81-coverage, depth)
+	log.Printf("Coverage: %v\nPossible Moves: %v\nMax Depth: %v\n", coverage, points-coverage, maxDepth)
 
 	// Player will not pass if <75% of board is covered
-	noPass := (float64(coverage) / math.Pow(float64(g.Board.Size()), 2)) < .75
-
-	eval, moves := minimax(g, depth, math.Inf(-1), math.Inf(1), true, noPass)
-	fmt.Printf("Eval Score: %v\nNum Equiv Moves: %v\n", eval, len(moves))
-
-	if len(moves) == 0 {
-		return p
+	noPass := float64(coverage)/float64(points) < .75
+
+	scores := []scoredMove{}
+	completed := 0
+	for depth := 1; depth <= maxDepth; depth++ {
+		_, bestMoves := bestScored(scores)
+		moves := rootMoves(g, noPass, bestMoves)
+		if n, ok := s.evaluator.(*Network); ok && n.Size() == g.Board.Size() && len(bestMoves) == 0 {
+			// with no earlier search to go by, try the moves the policy favours first
+			n.orderMoves(g, moves)
+		}
+		next := s.root(g, depth, moves, config.Workers, noPass, exact)
+		if s.aborted {
+			// an unfinished search is only used if no search has finished
+			if completed == 0 {
+				scores = next
+				if len(scores) == 0 {
+					// time ran out before any move was searched, which mustn't make the computer pass
+					scores = s.fallback(g, moves)
+				}
+			}
+			break
+		}
+		scores, completed = next, depth
 	}
-
-	return SelectMove(color, moves)
+	return scores, completed
 }
@@ -0,0 +1,64 @@
+package player
+
+import (
+	"context"
+	"testing"
+
+	"go-api/game"
+)
+
+func TestSearchOutOfTime(t *testing.T) {
+	g, err := game.FromSGF("(;SZ[9]KM[7.5];B[ee];W[ce];B[ge])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the deadline has passed before the search starts
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := SearchContext(ctx, g, g.Turn, DefaultConfig, DefaultSearchConfig)
+	if result.Move.X < 0 || !g.IsValidMove(result.Move) {
+		t.Errorf("got move %+v, want a legal stone", result.Move)
+	}
+	if result.Depth != 0 {
+		t.Errorf("got depth %d, want 0", result.Depth)
+	}
+}
+
+// searching the root moves in parallel shares them out differently, but mustn't change their scores
+func TestSearchWorkersAgree(t *testing.T) {
+	g, err := game.FromSGF("(;SZ[5]KM[0.5];B[cc];W[dd];B[dc];W[cd])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exact := range []bool{false, true} {
+		scores := map[int]map[[2]int]float64{}
+		best := map[int]float64{}
+		for _, workers := range []int{1, 4} {
+			s := &search{ctx: context.Background(), evaluator: DefaultConfig, table: newTranspositionTable(16)}
+			result, depth := s.deepen(g, SearchConfig{Workers: workers, MaxDepth: 3}, exact)
+			if depth != 3 {
+				t.Fatalf("%d workers: searched to depth %d, want 3", workers, depth)
+			}
+			scores[workers] = map[[2]int]float64{}
+			for _, sm := range result {
+				scores[workers][[2]int{sm.move.X, sm.move.Y}] = sm.score
+			}
+			best[workers], _ = bestScored(result)
+		}
+		if best[1] != best[4] {
+			t.Errorf("exact %v: got best score %v with 4 workers, %v with 1", exact, best[4], best[1])
+		}
+		if !exact {
+			// other moves are only searched far enough to show they're no better, which depends on the order
+			continue
+		}
+		if len(scores[1]) != len(scores[4]) {
+			t.Errorf("got %d moves with 4 workers, %d with 1", len(scores[4]), len(scores[1]))
+		}
+		for p, score := range scores[1] {
+			if scores[4][p] != score {
+				t.Errorf("(%d, %d): got score %v with 4 workers, %v with 1", p[0], p[1], scores[4][p], score)
+			}
+		}
+	}
+}
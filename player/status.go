@@ -0,0 +1,128 @@
+package player
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	"go-api/game"
+)
+
+// statuses of groups at the end of a game
+const (
+	StatusAlive = "alive"
+	StatusDead  = "dead"
+	StatusSeki  = "seki" // alive without eyes of its own, so the points around it are no one's
+)
+
+// GroupStatus is the estimated status of a group on the board
+type GroupStatus struct {
+	ID        string   `json:"id"`
+	Color     string   `json:"color"`
+	Status    string   `json:"status"`
+	Stones    [][2]int `json:"stones"`
+	Liberties int      `json:"liberties"`
+	Eyes      int      `json:"eyes"`     // see game.GameBoard.Eyes
+	Survival  float64  `json:"survival"` // share of the playouts the group survived
+}
+
+// StatusConfig limits the playouts run to estimate the status of groups
+type StatusConfig struct {
+	Playouts  int           // most playouts to run
+	TimeLimit time.Duration // stop running playouts after this long (0 for no limit)
+	Workers   int           // playouts run in parallel
+}
+
+var DefaultStatusConfig = StatusConfig{
+	Playouts:  200,
+	TimeLimit: 2 * time.Second,
+	Workers:   runtime.NumCPU(),
+}
+
+// EstimateStatus estimates whether each group on the board is alive, dead or in seki
+// groups with two eyes live and groups in seki (see game.GameBoard.Seki) are left alone;
+// the rest are dead if they're captured in most of the playouts from the position,
+// half of which start with each player to move
+func EstimateStatus(g game.Game, config StatusConfig) []GroupStatus {
+	g = g.DeepCopy()
+	// play on from the position even if the players have passed
+	g.Ended, g.Counting, g.Passed, g.Dead = false, false, false, nil
+
+	groups := sortedGroups(g.Board)
+	survived := make([]int, len(groups))
+	playouts := 0
+	start := time.Now()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < config.Workers; w++ {
+		wg.Add(1)
+		go func(r *rand.Rand) {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if playouts >= config.Playouts || (config.TimeLimit > 0 && time.Since(start) >= config.TimeLimit) {
+					mu.Unlock()
+					return
+				}
+				i := playouts
+				playouts++
+				mu.Unlock()
+
+				pos := g.DeepCopy()
+				pos.Turn = "black"
+				if i%2 == 1 {
+					pos.Turn = "white"
+				}
+				end := playOut(pos, PolicyLight, r)
+				mu.Lock()
+				for j, grp := range groups {
+					first := grp.Points[0]
+					if end.Board.At(first.X, first.Y).Color == grp.Color {
+						survived[j]++
+					}
+				}
+				mu.Unlock()
+			}
+		}(newRand())
+	}
+	wg.Wait()
+
+	seki := g.Board.Seki()
+	statuses := make([]GroupStatus, 0, len(groups))
+	for j, grp := range groups {
+		s := GroupStatus{
+			ID:        grp.ID,
+			Color:     grp.Color,
+			Status:    StatusAlive,
+			Stones:    [][2]int{},
+			Liberties: grp.CountLiberties(g.Board),
+			Eyes:      g.Board.Eyes(grp),
+		}
+		for _, p := range grp.Points {
+			s.Stones = append(s.Stones, [2]int{p.X, p.Y})
+		}
+		if playouts > 0 {
+			s.Survival = float64(survived[j]) / float64(playouts)
+		}
+		switch {
+		case seki[grp.ID]:
+			s.Status = StatusSeki
+		case s.Eyes < 2 && playouts > 0 && 2*survived[j] < playouts:
+			s.Status = StatusDead
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// the stones of every group estimated dead, as marked in game.Game.Dead
+func DeadStones(statuses []GroupStatus) [][2]int {
+	dead := [][2]int{}
+	for _, s := range statuses {
+		if s.Status == StatusDead {
+			dead = append(dead, s.Stones...)
+		}
+	}
+	return dead
+}
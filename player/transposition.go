@@ -0,0 +1,165 @@
+package player
+
+import (
+	"sync"
+	"unsafe"
+
+	"go-api/game"
+)
+
+// how an entry's score relates to the true value of its position
+const (
+	boundExact = iota
+	boundLower // the position is worth at least score
+	boundUpper // the position is worth at most score
+)
+
+// best move values which aren't a point on the board
+const (
+	noMove   = -2
+	passMove = -1
+)
+
+// the result of searching a position to some depth
+type tableEntry struct {
+	key   uint64
+	score float64
+	depth int16
+	bound int8
+	move  int16 // best move found, as y*size+x (or passMove, noMove)
+}
+
+// a transposition table remembers positions already searched, so that a position
+// reached by a different order of moves (or in a later iteration) needn't be searched again
+// entries are replaced when a deeper search of another position lands in the same slot
+// a table may be shared by searches running in parallel
+type transpositionTable struct {
+	mu      sync.Mutex
+	entries []tableEntry
+	mask    uint64
+
+	probes int
+	hits   int
+	cuts   int // hits which made searching the position unnecessary
+}
+
+// create a table using at most the given number of megabytes
+// returns nil (which is safe to use) if that is too little for a single entry
+func newTranspositionTable(megabytes int) *transpositionTable {
+	n := uint64(megabytes) << 20 / uint64(unsafe.Sizeof(tableEntry{}))
+	if n == 0 {
+		return nil
+	}
+	// round down to a power of two so an index is just the low bits of a key
+	for n&(n-1) != 0 {
+		n &= n - 1
+	}
+	return &transpositionTable{
+		entries: make([]tableEntry, n),
+		mask:    n - 1,
+	}
+}
+
+// keys to mix into the board hash for the parts of a game's state that aren't on the board
+const (
+	whiteToMoveKey = 0x9e3779b97f4a7c15
+	passedKey      = 0xc2b2ae3d27d4eb4f
+	koKey          = 0x165667b19e3779f9
+)
+
+// key identifying a game's position and everything else that affects how play continues
+// (0 is never used, so that empty entries never match)
+func tableKey(g game.Game) uint64 {
+	key := g.Board.Hash
+	if g.Turn == "white" {
+		key ^= whiteToMoveKey
+	}
+	if g.Passed {
+		key ^= passedKey
+	}
+	if g.Ko[0] >= 0 {
+		key ^= koKey * uint64(g.Ko[1]*g.Board.Size()+g.Ko[0]+1)
+	}
+	if key == 0 {
+		key = 1
+	}
+	return key
+}
+
+func (t *transpositionTable) probe(key uint64) (tableEntry, bool) {
+	if t == nil {
+		return tableEntry{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.probes++
+	e := t.entries[key&t.mask]
+	if e.key != key {
+		return tableEntry{}, false
+	}
+	t.hits++
+	return e, true
+}
+
+// probe without counting towards the table's statistics (the table mustn't be nil)
+func (t *transpositionTable) lookup(key uint64) (tableEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entries[key&t.mask]
+	return e, e.key == key
+}
+
+func (t *transpositionTable) store(e tableEntry) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	slot := &t.entries[e.key&t.mask]
+	// keep the result of a deeper search of a different position
+	if slot.key != e.key && slot.key != 0 && slot.depth > e.depth {
+		return
+	}
+	// a position searched again without finding a best move keeps the one it had
+	if slot.key == e.key && e.move == noMove {
+		e.move = slot.move
+	}
+	*slot = e
+}
+
+// count a probe whose entry made searching its position unnecessary
+func (t *transpositionTable) cut() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cuts++
+}
+
+// table entry move for a point on a board of the given size
+func encodeMove(p game.Point, size int) int16 {
+	if p.X < 0 {
+		return passMove
+	}
+	return int16(p.Y*size + p.X)
+}
+
+// TableStats describes how useful a search's transposition table was
+type TableStats struct {
+	Size    int     `json:"size"` // number of entries
+	Probes  int     `json:"probes"`
+	Hits    int     `json:"hits"`
+	Cutoffs int     `json:"cutoffs"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+func (t *transpositionTable) stats() TableStats {
+	if t == nil {
+		return TableStats{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := TableStats{Size: len(t.entries), Probes: t.probes, Hits: t.hits, Cutoffs: t.cuts}
+	if t.probes > 0 {
+		stats.HitRate = float64(t.hits) / float64(t.probes)
+	}
+	return stats
+}
@@ -0,0 +1,238 @@
+package player
+
+import (
+	"context"
+	"math"
+	"testing"
+	"unsafe"
+
+	"go-api/game"
+)
+
+// scores every position the same
+type constEvaluator float64
+
+func (e constEvaluator) Evaluate(g game.Game, color string) float64 {
+	return float64(e)
+}
+
+func TestNewTranspositionTable(t *testing.T) {
+	entrySize := int(unsafe.Sizeof(tableEntry{}))
+	for _, megabytes := range []int{1, 3, 16} {
+		table := newTranspositionTable(megabytes)
+		n := len(table.entries)
+		if n&(n-1) != 0 || uint64(n-1) != table.mask {
+			t.Errorf("%dMB: got %d entries with mask %#x, want a power of two", megabytes, n, table.mask)
+		}
+		// the largest power of two which fits
+		if limit := megabytes << 20; n*entrySize > limit || 2*n*entrySize <= limit {
+			t.Errorf("%dMB: got %d entries of %d bytes", megabytes, n, entrySize)
+		}
+	}
+
+	// a table too small for any entries is nil, which does nothing
+	table := newTranspositionTable(0)
+	if table != nil {
+		t.Fatalf("got %d entries for 0MB, want none", len(table.entries))
+	}
+	table.store(tableEntry{key: 1, depth: 1})
+	if _, ok := table.probe(1); ok {
+		t.Error("found an entry in a nil table")
+	}
+	if stats := table.stats(); stats != (TableStats{}) {
+		t.Errorf("got stats %+v for a nil table", stats)
+	}
+}
+
+func TestTableStore(t *testing.T) {
+	table := newTranspositionTable(1)
+	// a key landing in the same slot as 1
+	other := uint64(1) + table.mask + 1
+	tests := []struct {
+		name   string
+		stored []tableEntry
+		key    uint64
+		want   tableEntry
+		found  bool
+	}{
+		{
+			name:   "empty slot",
+			stored: []tableEntry{{key: 1, score: 2, depth: 3, bound: boundLower, move: 4}},
+			key:    1,
+			want:   tableEntry{key: 1, score: 2, depth: 3, bound: boundLower, move: 4},
+			found:  true,
+		},
+		{
+			name:   "not stored",
+			stored: []tableEntry{{key: 1, depth: 3, move: 4}},
+			key:    other,
+		},
+		{
+			name: "same position searched again",
+			stored: []tableEntry{
+				{key: 1, score: 2, depth: 3, bound: boundLower, move: 4},
+				{key: 1, score: 5, depth: 1, bound: boundExact, move: 6},
+			},
+			key:   1,
+			want:  tableEntry{key: 1, score: 5, depth: 1, bound: boundExact, move: 6},
+			found: true,
+		},
+		{
+			name: "same position searched again without a best move",
+			stored: []tableEntry{
+				{key: 1, score: 2, depth: 3, bound: boundLower, move: 4},
+				{key: 1, score: 5, depth: 4, bound: boundUpper, move: noMove},
+			},
+			key:   1,
+			want:  tableEntry{key: 1, score: 5, depth: 4, bound: boundUpper, move: 4},
+			found: true,
+		},
+		{
+			name: "deeper search of another position kept",
+			stored: []tableEntry{
+				{key: 1, score: 2, depth: 3, move: 4},
+				{key: other, score: 5, depth: 2, move: 6},
+			},
+			key:   1,
+			want:  tableEntry{key: 1, score: 2, depth: 3, move: 4},
+			found: true,
+		},
+		{
+			name: "replaced by as deep a search of another position",
+			stored: []tableEntry{
+				{key: 1, score: 2, depth: 3, move: 4},
+				{key: other, score: 5, depth: 3, move: 6},
+			},
+			key:   other,
+			want:  tableEntry{key: other, score: 5, depth: 3, move: 6},
+			found: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := newTranspositionTable(1)
+			for _, e := range test.stored {
+				table.store(e)
+			}
+			e, ok := table.lookup(test.key)
+			if ok != test.found || (ok && e != test.want) {
+				t.Errorf("got %+v (found %v), want %+v (found %v)", e, ok, test.want, test.found)
+			}
+		})
+	}
+}
+
+func TestTableCutoffs(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry tableEntry // for the searched position
+		alpha float64
+		beta  float64
+		cut   bool
+	}{
+		{"exact", tableEntry{score: 5, depth: 2, bound: boundExact}, -10, 10, true},
+		{"exact from a deeper search", tableEntry{score: 5, depth: 3, bound: boundExact}, -10, 10, true},
+		{"exact from a shallower search", tableEntry{score: 5, depth: 1, bound: boundExact}, -10, 10, false},
+		{"lower bound at least beta", tableEntry{score: 5, depth: 2, bound: boundLower}, -10, 5, true},
+		{"lower bound below beta", tableEntry{score: 5, depth: 2, bound: boundLower}, -10, 10, false},
+		{"upper bound at most alpha", tableEntry{score: -5, depth: 2, bound: boundUpper}, -5, 10, true},
+		{"upper bound above alpha", tableEntry{score: -5, depth: 2, bound: boundUpper}, -10, 10, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := game.FromSGF("(;SZ[3];B[aa];W[cc])")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &search{ctx: context.Background(), evaluator: constEvaluator(0), table: newTranspositionTable(1)}
+			e := test.entry
+			e.key, e.move = tableKey(g), encodeMove(game.Point{X: 1, Y: 1}, 3)
+			s.table.store(e)
+
+			score, moves := s.minimax(g, 2, test.alpha, test.beta, true, true)
+			cut := s.table.stats().Cutoffs == 1
+			if cut != test.cut {
+				t.Fatalf("got cutoff %v, want %v", cut, test.cut)
+			}
+			if !cut {
+				// the position was searched, with every position scoring 0
+				if score != 0 {
+					t.Errorf("got score %v from searching, want 0", score)
+				}
+				return
+			}
+			if score != e.score {
+				t.Errorf("got score %v, want the table's %v", score, e.score)
+			}
+			if len(moves) != 1 || moves[0].X != 1 || moves[0].Y != 1 {
+				t.Errorf("got moves %v, want the table's best move (1, 1)", moves)
+			}
+			if s.nodes != 1 {
+				t.Errorf("visited %d nodes, want only the position itself", s.nodes)
+			}
+		})
+	}
+}
+
+func TestTableStats(t *testing.T) {
+	table := newTranspositionTable(1)
+	table.store(tableEntry{key: 1, depth: 1})
+	table.store(tableEntry{key: 2, depth: 1})
+	for _, key := range []uint64{1, 2, 3, 1 + table.mask + 1} {
+		table.probe(key)
+	}
+	table.cut()
+	// lookups don't count
+	table.lookup(1)
+	want := TableStats{Size: len(table.entries), Probes: 4, Hits: 2, Cutoffs: 1, HitRate: 0.5}
+	if stats := table.stats(); stats != want {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+}
+
+// a table saves searching positions again, but mustn't change the result of a search
+func TestSearchTableAgrees(t *testing.T) {
+	for _, sgf := range []string{
+		"(;SZ[5]KM[0.5];B[cc];W[dd])",
+		"(;SZ[5]KM[0.5];B[bb];W[cb];B[bc];W[cc];B[bd])",
+	} {
+		t.Run(sgf, func(t *testing.T) {
+			g, err := game.FromSGF(sgf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scores := map[int]map[[2]int]float64{}
+			best := map[int]float64{}
+			nodes := map[int]int{}
+			for _, megabytes := range []int{0, 16} {
+				Seed(1)
+				s := &search{ctx: context.Background(), evaluator: DefaultConfig, table: newTranspositionTable(megabytes)}
+				// search every root move exactly, so that every score can be compared
+				result, depth := s.deepen(g, SearchConfig{Workers: 1, MaxDepth: 4}, true)
+				if depth != 4 {
+					t.Fatalf("%dMB: searched to depth %d, want 4", megabytes, depth)
+				}
+				scores[megabytes] = map[[2]int]float64{}
+				for _, sm := range result {
+					scores[megabytes][[2]int{sm.move.X, sm.move.Y}] = sm.score
+				}
+				best[megabytes], _ = bestScored(result)
+				nodes[megabytes] = s.nodes
+			}
+			if best[0] != best[16] || math.IsInf(best[0], 0) {
+				t.Errorf("got best score %v with a table, %v without", best[16], best[0])
+			}
+			if len(scores[0]) != len(scores[16]) {
+				t.Errorf("got %d moves with a table, %d without", len(scores[16]), len(scores[0]))
+			}
+			for p, score := range scores[0] {
+				if scores[16][p] != score {
+					t.Errorf("(%d, %d): got score %v with a table, %v without", p[0], p[1], scores[16][p], score)
+				}
+			}
+			if nodes[16] >= nodes[0] {
+				t.Errorf("visited %d nodes with a table, %d without", nodes[16], nodes[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"go-api/game"
+	"go-api/player"
+	"go-api/storage"
+)
+
+// a session wraps a single game so concurrent requests can't clobber it
+// every change to the game is saved to the store and announced to subscribers
+type session struct {
+	mu          sync.Mutex
+	game        game.Game
+	store       storage.Store
+	subscribers map[*subscriber]bool
+	config      player.EvalConfig // used by the AI for this game's moves
+}
+
+func newSession(g game.Game, store storage.Store) *session {
+	config := player.DefaultConfig
+	if len(g.EvalConfig) > 0 {
+		c, err := player.ParseEvalConfig(g.EvalConfig)
+		if err != nil {
+			log.Printf("game %d: evaluator config: %v", g.ID, err)
+		} else {
+			config = c
+		}
+	}
+	return &session{
+		game:        g,
+		store:       store,
+		subscribers: map[*subscriber]bool{},
+		config:      config,
+	}
+}
+
+// the methods below change the game; callers must hold the session's lock
+
+func (s *session) play(p game.Point) bool {
+	if !s.game.IsValidMove(p) {
+		return false
+	}
+	s.game.Play(p)
+	s.changed(eventMove)
+	return true
+}
+
+func (s *session) pass() {
+	s.game.Pass()
+	s.changed(eventPass)
+	if s.game.Counting {
+		// start the count from an estimate of which stones are dead, for the players to correct
+		// the estimate takes a while, so it runs on a copy without holding the lock
+		go s.estimateDead(s.game.DeepCopy())
+	}
+}
+
+// mark the stones the estimate for g finds dead, unless the count has moved on
+// or the players have already started marking it themselves
+func (s *session) estimateDead(g game.Game) {
+	dead := player.DeadStones(player.EstimateStatus(g, player.DefaultStatusConfig))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.game.Counting || len(s.game.Moves) != len(g.Moves) || len(s.game.Dead) > 0 || len(s.game.Accepted) > 0 || len(dead) == 0 {
+		return
+	}
+	s.game.Dead = dead
+	s.changed(eventDead)
+}
+
+func (s *session) resign() bool {
+	if !s.game.Resign(s.game.Turn) {
+		return false
+	}
+	s.changed(eventResign)
+	return true
+}
+
+// choose the evaluator config the AI uses in this game, saving it with the game
+func (s *session) setConfig(config player.EvalConfig) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		log.Printf("game %d: evaluator config: %v", s.game.ID, err)
+		return
+	}
+	s.config = config
+	s.game.EvalConfig = data
+	if err := s.store.Save(s.game); err != nil {
+		log.Printf("saving game %d: %v", s.game.ID, err)
+	}
+}
+
+// mark the group at (x, y) dead, or alive again, while the game is being counted
+func (s *session) toggleDead(x, y int) bool {
+	if !s.game.ToggleDead(x, y) {
+		return false
+	}
+	s.changed(eventDead)
+	return true
+}
+
+func (s *session) accept(color string) bool {
+	if !s.game.AcceptCount(color) {
+		return false
+	}
+	s.changed(eventAccept)
+	return true
+}
+
+func (s *session) resume() bool {
+	if !s.game.ResumePlay() {
+		return false
+	}
+	s.changed(eventResume)
+	return true
+}
+
+// save the game and let subscribers know what happened
+func (s *session) changed(kind string) {
+	if err := s.store.Save(s.game); err != nil {
+		log.Printf("saving game %d: %v", s.game.ID, err)
+	}
+	s.publish(newEvent(kind, s.game))
+	if s.game.Ended && (kind == eventMove || kind == eventPass || kind == eventResign || kind == eventAccept) {
+		s.publish(newEvent(eventGameOver, s.game))
+	}
+}
+
+// registry keeps track of every game being played, keyed by game ID
+// games are persisted to the store, which assigns their IDs
+type registry struct {
+	mu       sync.Mutex
+	sessions map[int]*session
+	store    storage.Store
+}
+
+func newRegistry(store storage.Store) *registry {
+	return &registry{
+		sessions: map[int]*session{},
+		store:    store,
+	}
+}
+
+// reload every game that was still in progress when the server last stopped
+func (r *registry) restore() error {
+	games, err := r.store.LoadInProgress()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, g := range games {
+		r.sessions[g.ID] = newSession(g, r.store)
+	}
+	return nil
+}
+
+// save a new game and add it to the registry
+func (r *registry) create(g game.Game) (int, *session, error) {
+	if err := r.store.Create(&g); err != nil {
+		return 0, nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := newSession(g, r.store)
+	r.sessions[g.ID] = s
+	return g.ID, s, nil
+}
+
+func (r *registry) get(id int) (*session, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	return s, ok
+}
+
+func (r *registry) ids() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]int, 0, len(r.sessions))
+	for id := range r.sessions {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// look up the game named by the :id route parameter, responding with an error if there is none
+func findSession(c *gin.Context) (*session, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "Bad Request", "message": "game id invalid"})
+		return nil, false
+	}
+	s, ok := Games.get(id)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"status": "Not Found", "message": "game not found"})
+		return nil, false
+	}
+	return s, true
+}
+
+// middleware that looks up the game named by the :id route parameter
+// and holds its lock for the remainder of the request
+func loadSession(c *gin.Context) {
+	s, ok := findSession(c)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.Set("session", s)
+	c.Next()
+}
+
+func currentSession(c *gin.Context) *session {
+	return c.MustGet("session").(*session)
+}
+
+// game belonging to the current request (see loadSession)
+func currentGame(c *gin.Context) *game.Game {
+	return &currentSession(c).game
+}
@@ -0,0 +1,349 @@
+// Package selfplay pits the engines against each other and records every
+// position as training and regression data.
+//
+// The dataset is written as JSON Lines (one JSON object per line), gzipped
+// when the output file name ends in ".gz". Each line describes one position
+// and the move chosen in it:
+//
+//	game     int     index of the game in the run, starting at 0
+//	move     int     number of moves played before this position
+//	size     int     board size
+//	komi     float   komi given to white
+//	board    string  size*size characters, row by row from the top left:
+//	                 '.' empty, 'X' black, 'O' white
+//	ko       [x, y]  point where a stone may not be played due to ko, [-1, -1] if none
+//	to_move  string  "black" or "white"
+//	engine   string  engine that chose the move ("minimax", "mcts" or "random")
+//	x, y     int     point played (0-based from the top left), -1 for a pass
+//	pass     bool    the move was a pass
+//	score    float   search evaluation from to_move's point of view: minimax's static
+//	                 evaluation or mcts's win rate (omitted for random)
+//	result   string  final result of the game, e.g. "B+3.5", "W+R" or "Draw"
+//	winner   string  "black", "white" or "" for a draw
+package selfplay
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"go-api/game"
+	"go-api/player"
+)
+
+// engines which can take part in self-play
+const (
+	EngineMinimax = "minimax"
+	EngineMCTS    = "mcts"
+	EngineRandom  = "random"
+)
+
+type Options struct {
+	Games     int
+	Size      int
+	Komi      float64
+	Seed      int64
+	Black     string // engine playing black (white when Alternate swaps colors)
+	White     string
+	Playouts  int  // playouts per move for the mcts engine
+	Alternate bool // swap colors every other game
+}
+
+var DefaultOptions = Options{
+	Games:     10,
+	Size:      9,
+	Komi:      7.5,
+	Seed:      1,
+	Black:     EngineMinimax,
+	White:     EngineRandom,
+	Playouts:  500,
+	Alternate: true,
+}
+
+// a single line of the dataset (see the package documentation)
+type Record struct {
+	Game   int      `json:"game"`
+	Move   int      `json:"move"`
+	Size   int      `json:"size"`
+	Komi   float64  `json:"komi"`
+	Board  string   `json:"board"`
+	Ko     [2]int   `json:"ko"`
+	ToMove string   `json:"to_move"`
+	Engine string   `json:"engine"`
+	X      int      `json:"x"`
+	Y      int      `json:"y"`
+	Pass   bool     `json:"pass"`
+	Score  *float64 `json:"score,omitempty"`
+	Result string   `json:"result"`
+	Winner string   `json:"winner"`
+}
+
+// encode the stones on the board as a string (see the package documentation)
+func EncodeBoard(b game.GameBoard) string {
+	var sb strings.Builder
+	for _, row := range b.Points() {
+		for _, p := range row {
+			switch p.Color {
+			case "black":
+				sb.WriteByte('X')
+			case "white":
+				sb.WriteByte('O')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+func validEngine(engine string) bool {
+	return engine == EngineMinimax || engine == EngineMCTS || engine == EngineRandom
+}
+
+// choose a move with the named engine, returning its evaluation if it has one
+func chooseMove(g game.Game, engine string, opts Options) (game.Point, *float64, error) {
+	switch engine {
+	case EngineMinimax:
+		config := player.DefaultSearchConfig
+		config.Workers = 1 // the order parallel searches finish in would make runs irreproducible
+		result := player.SearchContext(context.Background(), g, g.Turn, player.DefaultConfig, config)
+		move, score := result.Move, result.Score
+		// evaluation sums vary in their last digits with map iteration order
+		score = math.Round(score*1e6) / 1e6
+		return move, &score, nil
+	case EngineMCTS:
+		config := player.DefaultMCTSConfig
+		config.Playouts = opts.Playouts
+		config.TimeLimit = 0 // a time limit would make runs irreproducible
+		move, winRate, err := player.MCTSSearch(g, g.Turn, config)
+		if err != nil {
+			return move, nil, err
+		}
+		return move, &winRate, nil
+	default:
+		return player.RandomMove(g, g.Turn), nil, nil
+	}
+}
+
+// PlayGame plays g to the end, asking chooseMove for a move for the player to move each turn
+// a pass, or a move which isn't legal, is played as a pass, and a game which goes on for too
+// long is passed out; the board is then counted as it stands, since engines don't mark dead stones
+// an error from chooseMove stops the game and is returned
+func PlayGame(g *game.Game, chooseMove func(g game.Game) (game.Point, error)) error {
+	limit := 3 * g.Board.Size() * g.Board.Size()
+	for !g.Over() {
+		if len(g.Moves) >= limit {
+			// the engines are going round in circles, so count the board as it stands
+			g.Pass()
+			continue
+		}
+		move, err := chooseMove(*g)
+		if err != nil {
+			return err
+		}
+		move.Color = g.Turn
+		if move.X < 0 || !g.IsValidMove(move) {
+			g.Pass()
+		} else {
+			g.Play(move)
+		}
+	}
+	g.FinishCounting()
+	return nil
+}
+
+// play a single game, returning a record of every position
+func playGame(index int, opts Options) ([]Record, error) {
+	komi := opts.Komi
+	g, err := game.NewGame(game.Settings{Size: opts.Size, Komi: &komi})
+	if err != nil {
+		return nil, err
+	}
+	engines := map[string]string{"black": opts.Black, "white": opts.White}
+	if opts.Alternate && index%2 == 1 {
+		engines["black"], engines["white"] = opts.White, opts.Black
+	}
+
+	records := []Record{}
+	err = PlayGame(&g, func(g game.Game) (game.Point, error) {
+		engine := engines[g.Turn]
+		move, score, err := chooseMove(g, engine, opts)
+		if err != nil {
+			return move, err
+		}
+		records = append(records, Record{
+			Game:   index,
+			Move:   len(g.Moves),
+			Size:   opts.Size,
+			Komi:   komi,
+			Board:  EncodeBoard(g.Board),
+			Ko:     g.Ko,
+			ToMove: g.Turn,
+			Engine: engine,
+			X:      move.X,
+			Y:      move.Y,
+			Score:  score,
+		})
+		return move, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		// record what was actually played, which is a pass if the move chosen wasn't legal
+		if m := g.Moves[records[i].Move]; m.Pass {
+			records[i].X, records[i].Y, records[i].Pass = -1, -1, true
+		}
+		records[i].Result = g.Result
+		records[i].Winner = g.Winner
+	}
+	return records, nil
+}
+
+// Run plays opts.Games games, writing every position to w as it goes
+// progress is reported to log (if not nil)
+func Run(opts Options, w io.Writer, log io.Writer) error {
+	if opts.Games < 1 {
+		return fmt.Errorf("number of games must be at least 1")
+	}
+	if !validEngine(opts.Black) || !validEngine(opts.White) {
+		return fmt.Errorf("engines must be %q, %q or %q", EngineMinimax, EngineMCTS, EngineRandom)
+	}
+	if (opts.Black == EngineMCTS || opts.White == EngineMCTS) && opts.Playouts < 1 {
+		return fmt.Errorf("playouts must be at least 1 for the mcts engine")
+	}
+	player.Seed(opts.Seed)
+	enc := json.NewEncoder(w)
+	wins := map[string]int{}
+	for i := 0; i < opts.Games; i++ {
+		records, err := playGame(i, opts)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		result := "?"
+		if len(records) > 0 {
+			result = records[0].Result
+			if winner := records[0].Winner; winner != "" {
+				engine := opts.Black
+				if (winner == "white") != (opts.Alternate && i%2 == 1) {
+					engine = opts.White
+				}
+				wins[engine]++
+			}
+		}
+		if log != nil {
+			fmt.Fprintf(log, "game %d: %s after %d moves\n", i, result, len(records))
+		}
+	}
+	if log != nil {
+		fmt.Fprintf(log, "wins: %v\n", wins)
+	}
+	return nil
+}
+
+// RunToFile is Run writing to the named file, gzipped if the name ends in ".gz"
+func RunToFile(opts Options, path string, log io.Writer) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	err = Run(opts, w, log)
+	if zw != nil {
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Read reads the records of a dataset written by Run
+func Read(r io.Reader) ([]Record, error) {
+	records := []Record{}
+	dec := json.NewDecoder(r)
+	for {
+		var record Record
+		if err := dec.Decode(&record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("record %d: %v", len(records), err)
+		}
+		records = append(records, record)
+	}
+}
+
+// ReadFile is Read from the named file, gunzipped if the name ends in ".gz"
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	records, err := Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return records, nil
+}
+
+// the position a record describes, with its stones as setup stones
+// (the ko point is recorded in Game.Ko for the network's features, not enforced)
+func (r Record) Position() (game.Game, error) {
+	komi := r.Komi
+	g, err := game.NewGame(game.Settings{Size: r.Size, Komi: &komi})
+	if err != nil {
+		return game.Game{}, err
+	}
+	if len(r.Board) != r.Size*r.Size {
+		return game.Game{}, fmt.Errorf("board has %d points, not %d", len(r.Board), r.Size*r.Size)
+	}
+	for i, c := range r.Board {
+		p := game.Point{X: i % r.Size, Y: i / r.Size}
+		switch c {
+		case 'X':
+			p.Color = "black"
+		case 'O':
+			p.Color = "white"
+		case '.':
+			continue
+		default:
+			return game.Game{}, fmt.Errorf("unknown point %q on the board", c)
+		}
+		if !g.AddSetupStone(p) {
+			return game.Game{}, fmt.Errorf("can't place a stone at %d,%d", p.X, p.Y)
+		}
+	}
+	if r.ToMove != "black" && r.ToMove != "white" {
+		return game.Game{}, fmt.Errorf("unknown player to move %q", r.ToMove)
+	}
+	g.Turn = r.ToMove
+	g.Ko = r.Ko
+	return g, nil
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+
+	"go-api/game"
+)
+
+// schema changes, applied in order; never edit one that has been released, add a new one
+var migrations = []string{
+	`CREATE TABLE games (
+		id         serial PRIMARY KEY,
+		size       integer NOT NULL,
+		komi       double precision NOT NULL,
+		rules      text NOT NULL,
+		superko    text NOT NULL,
+		ended      boolean NOT NULL DEFAULT false,
+		winner     text NOT NULL DEFAULT '',
+		result     text NOT NULL DEFAULT '',
+		created_at timestamptz NOT NULL DEFAULT now(),
+		updated_at timestamptz NOT NULL DEFAULT now()
+	);
+	CREATE TABLE moves (
+		game_id integer NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+		number  integer NOT NULL,
+		setup   boolean NOT NULL DEFAULT false,
+		color   text NOT NULL,
+		x       integer NOT NULL,
+		y       integer NOT NULL,
+		pass    boolean NOT NULL DEFAULT false,
+		resign  boolean NOT NULL DEFAULT false,
+		PRIMARY KEY (game_id, setup, number)
+	);
+	CREATE INDEX games_in_progress ON games (id) WHERE NOT ended;`,
+	`ALTER TABLE games ADD COLUMN move_time double precision NOT NULL DEFAULT 0;`,
+	`ALTER TABLE games ADD COLUMN level text NOT NULL DEFAULT '';`,
+	`ALTER TABLE games ADD COLUMN scoring text NOT NULL DEFAULT '';`,
+	`ALTER TABLE games
+		ADD COLUMN counting boolean NOT NULL DEFAULT false,
+		ADD COLUMN dead     text NOT NULL DEFAULT '[]',
+		ADD COLUMN accepted text NOT NULL DEFAULT '';`,
+	`ALTER TABLE games ADD COLUMN suicide boolean NOT NULL DEFAULT false;`,
+	`ALTER TABLE games ADD COLUMN turn text NOT NULL DEFAULT '';`,
+	`ALTER TABLE games ADD COLUMN eval_config text NOT NULL DEFAULT '';`,
+}
+
+// PostgresStore saves games to a PostgreSQL database
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// connect to the database and bring its schema up to date
+func OpenPostgres(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// apply any migrations not yet recorded in schema_migrations
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    integer PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return err
+	}
+	var current int
+	if err := db.QueryRow(`SELECT coalesce(max(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %v", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %v", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) Create(g *game.Game) error {
+	settings := g.Settings()
+	err := s.db.QueryRow(
+		`INSERT INTO games (size, komi, rules, superko, scoring, suicide, move_time, level)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		settings.Size, *settings.Komi, settings.Rules, settings.Superko, settings.Scoring, *settings.Suicide,
+		settings.MoveTime, settings.Level,
+	).Scan(&g.ID)
+	if err != nil {
+		return err
+	}
+	g.Board.ID = g.ID
+	return s.Save(*g)
+}
+
+// a game as the database stores it: its settings and record, and the state the record doesn't show
+type storedGame struct {
+	settings     game.Settings
+	setup, moves []game.Move
+	turn         string // the player to move before the first move
+	ended        bool
+	winner       string
+	result       string
+	counting     bool
+	dead         string // JSON list of points
+	accepted     string // comma separated colors
+	evalConfig   string
+}
+
+func newStoredGame(g game.Game) (storedGame, error) {
+	dead, err := json.Marshal(g.Dead)
+	if err != nil {
+		return storedGame{}, err
+	}
+	return storedGame{
+		settings:   g.Settings(),
+		setup:      g.SetupStones,
+		moves:      g.Moves,
+		turn:       g.StartingTurn(),
+		ended:      g.Ended,
+		winner:     g.Winner,
+		result:     g.Result,
+		counting:   g.Counting,
+		dead:       string(dead),
+		accepted:   strings.Join(g.Accepted, ","),
+		evalConfig: string(g.EvalConfig),
+	}, nil
+}
+
+// rebuild the game by replaying its record, which restores the board, captures, ko and result
+func (sg storedGame) game(id int) (game.Game, error) {
+	g, err := game.Replay(sg.settings, sg.setup, sg.turn, sg.moves)
+	if err != nil {
+		return game.Game{}, fmt.Errorf("game %d: %v", id, err)
+	}
+	g.ID = id
+	g.Board.ID = id
+	if sg.evalConfig != "" {
+		g.EvalConfig = json.RawMessage(sg.evalConfig)
+	}
+
+	// the record ends in two passes, but not how the counting that followed went
+	if g.Counting {
+		if err := json.Unmarshal([]byte(sg.dead), &g.Dead); err != nil {
+			return game.Game{}, fmt.Errorf("game %d: dead stones: %v", id, err)
+		}
+		if sg.accepted != "" {
+			g.Accepted = strings.Split(sg.accepted, ",")
+		}
+		switch {
+		case sg.ended:
+			g.FinishCounting()
+		case !sg.counting:
+			g.ResumePlay()
+		}
+	}
+	return g, nil
+}
+
+// overwrite the stored game, replacing its move record
+func (s *PostgresStore) Save(g game.Game) error {
+	sg, err := newStoredGame(g)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	settings := sg.settings
+	res, err := tx.Exec(
+		`UPDATE games SET size = $2, komi = $3, rules = $4, superko = $5, scoring = $6,
+			move_time = $7, level = $8, ended = $9, winner = $10, result = $11,
+			counting = $12, dead = $13, accepted = $14, suicide = $15, turn = $16, eval_config = $17,
+			updated_at = now()
+		WHERE id = $1`,
+		g.ID, settings.Size, *settings.Komi, settings.Rules, settings.Superko, settings.Scoring, settings.MoveTime,
+		settings.Level, sg.ended, sg.winner, sg.result, sg.counting, sg.dead, sg.accepted, *settings.Suicide,
+		sg.turn, sg.evalConfig,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.Exec(`DELETE FROM moves WHERE game_id = $1`, g.ID); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(
+		`INSERT INTO moves (game_id, number, setup, color, x, y, pass, resign) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for i, m := range sg.setup {
+		if _, err := insert.Exec(g.ID, i, true, m.Color, m.X, m.Y, false, false); err != nil {
+			return err
+		}
+	}
+	for i, m := range sg.moves {
+		if _, err := insert.Exec(g.ID, i, false, m.Color, m.X, m.Y, m.Pass, m.Resign); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) Load(id int) (game.Game, error) {
+	var sg storedGame
+	var komi float64
+	var suicide bool
+	err := s.db.QueryRow(
+		`SELECT size, komi, rules, superko, scoring, suicide, move_time, level, ended, counting, dead, accepted, turn,
+			eval_config
+		FROM games WHERE id = $1`, id,
+	).Scan(&sg.settings.Size, &komi, &sg.settings.Rules, &sg.settings.Superko, &sg.settings.Scoring, &suicide,
+		&sg.settings.MoveTime, &sg.settings.Level, &sg.ended, &sg.counting, &sg.dead, &sg.accepted, &sg.turn,
+		&sg.evalConfig)
+	if err == sql.ErrNoRows {
+		return game.Game{}, ErrNotFound
+	}
+	if err != nil {
+		return game.Game{}, err
+	}
+	sg.settings.Komi = &komi
+	sg.settings.Suicide = &suicide
+
+	rows, err := s.db.Query(
+		`SELECT setup, color, x, y, pass, resign FROM moves WHERE game_id = $1 ORDER BY setup DESC, number`, id,
+	)
+	if err != nil {
+		return game.Game{}, err
+	}
+	defer rows.Close()
+	sg.setup, sg.moves = []game.Move{}, []game.Move{}
+	for rows.Next() {
+		var isSetup bool
+		var m game.Move
+		if err := rows.Scan(&isSetup, &m.Color, &m.X, &m.Y, &m.Pass, &m.Resign); err != nil {
+			return game.Game{}, err
+		}
+		if isSetup {
+			sg.setup = append(sg.setup, m)
+		} else {
+			sg.moves = append(sg.moves, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return game.Game{}, err
+	}
+	return sg.game(id)
+}
+
+func (s *PostgresStore) LoadInProgress() ([]game.Game, error) {
+	rows, err := s.db.Query(`SELECT id FROM games WHERE NOT ended ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	games := make([]game.Game, 0, len(ids))
+	for _, id := range ids {
+		g, err := s.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go-api/game"
+)
+
+// everything a stored game must bring back
+func summary(g game.Game) string {
+	var sb strings.Builder
+	for _, row := range g.Board.Points() {
+		for _, p := range row {
+			switch p.Color {
+			case "black":
+				sb.WriteByte('X')
+			case "white":
+				sb.WriteByte('O')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('/')
+	}
+	s := g.Settings()
+	fmt.Fprintf(&sb, " id=%d size=%d komi=%v rules=%s superko=%s scoring=%s suicide=%v move_time=%v level=%s",
+		g.ID, s.Size, *s.Komi, s.Rules, s.Superko, s.Scoring, *s.Suicide, s.MoveTime, s.Level)
+	fmt.Fprintf(&sb, " turn=%s first=%s captures=%v ko=%v passed=%v counting=%v dead=%v accepted=%v",
+		g.Turn, g.StartingTurn(), g.Captures, g.Ko, g.Passed, g.Counting, g.Dead, g.Accepted)
+	fmt.Fprintf(&sb, " ended=%v winner=%s result=%s setup=%d moves=%d positions=%d config=%s",
+		g.Ended, g.Winner, g.Result, len(g.SetupStones), len(g.Moves), len(g.Positions), g.EvalConfig)
+	return sb.String()
+}
+
+// games in each state the store has to remember
+func storedGames(t *testing.T) map[string]game.Game {
+	load := func(sgf string) game.Game {
+		g, err := game.FromSGF(sgf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	counting := func() game.Game {
+		g := load("(;SZ[5]KM[0.5]RU[Chinese];B[cc];W[aa];B[];W[])")
+		g.ToggleDead(0, 0)
+		return g
+	}
+
+	games := map[string]game.Game{}
+	games["new"] = load("(;SZ[9]KM[6.5]RU[Japanese])")
+	games["white to play first"] = load("(;SZ[9]AB[cc][gg]PL[W])")
+	games["white played first"] = load("(;SZ[9]AB[cc][gg]PL[W];W[ee];B[ce])")
+	games["ko"] = load("(;SZ[5];B[ba];W[ca];B[ab];W[db];B[bc];W[cc];B[cb];W[bb])")
+
+	g := counting()
+	games["counting"] = g
+	g = counting()
+	g.AcceptCount("white")
+	games["accepted by one player"] = g
+	g = counting()
+	g.AcceptCount("white")
+	g.AcceptCount("black")
+	games["counted"] = g
+	g = counting()
+	g.ResumePlay()
+	g.Play(game.Point{X: 4, Y: 4, Color: g.Turn})
+	games["resumed"] = g
+
+	g = load("(;SZ[9];B[ee];W[ff])")
+	g.Resign("black")
+	games["resigned"] = g
+	g = load("(;SZ[9]RU[NZ];B[ee])")
+	g.EvalConfig = json.RawMessage(`{"complexity":1000}`)
+	games["eval config"] = g
+	return games
+}
+
+// the moves as the database stores them, without the undo information kept in memory
+func stripped(moves []game.Move) []game.Move {
+	out := []game.Move{}
+	for _, m := range moves {
+		out = append(out, game.Move{Color: m.Color, X: m.X, Y: m.Y, Pass: m.Pass, Resign: m.Resign})
+	}
+	return out
+}
+
+func TestStoredGame(t *testing.T) {
+	for name, g := range storedGames(t) {
+		t.Run(name, func(t *testing.T) {
+			g.ID = 7
+			sg, err := newStoredGame(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sg.setup, sg.moves = stripped(sg.setup), stripped(sg.moves)
+			loaded, err := sg.game(g.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := summary(loaded), summary(g); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package storage persists games so they survive a restart of the server.
+package storage
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"go-api/game"
+)
+
+var ErrNotFound = errors.New("game not found")
+
+// Store saves and loads games along with their move records and results
+type Store interface {
+	// save a new game, assigning its ID
+	Create(g *game.Game) error
+	// save the current state of an existing game
+	Save(g game.Game) error
+	Load(id int) (game.Game, error)
+	// every game which has not yet ended
+	LoadInProgress() ([]game.Game, error)
+}
+
+// MemoryStore keeps games in memory, for tests and for running without a database
+type MemoryStore struct {
+	mu     sync.Mutex
+	games  map[int]game.Game
+	nextID int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{games: map[int]game.Game{}, nextID: 1}
+}
+
+func (m *MemoryStore) Create(g *game.Game) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g.ID = m.nextID
+	g.Board.ID = g.ID
+	m.nextID++
+	m.games[g.ID] = g.DeepCopy()
+	return nil
+}
+
+func (m *MemoryStore) Save(g game.Game) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.games[g.ID]; !ok {
+		return ErrNotFound
+	}
+	m.games[g.ID] = g.DeepCopy()
+	return nil
+}
+
+func (m *MemoryStore) Load(id int) (game.Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.games[id]
+	if !ok {
+		return game.Game{}, ErrNotFound
+	}
+	return g.DeepCopy(), nil
+}
+
+func (m *MemoryStore) LoadInProgress() ([]game.Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	games := []game.Game{}
+	for _, g := range m.games {
+		if !g.Ended {
+			games = append(games, g.DeepCopy())
+		}
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].ID < games[j].ID })
+	return games, nil
+}
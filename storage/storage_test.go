@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"testing"
+
+	"go-api/game"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	games := storedGames(t)
+	names := []string{}
+	for name, g := range games {
+		if err := store.Create(&g); err != nil {
+			t.Fatal(err)
+		}
+		games[name] = g
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		g := games[name]
+		loaded, err := store.Load(g.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got, want := summary(loaded), summary(g); got != want {
+			t.Errorf("%s: got  %s\nwant %s", name, got, want)
+		}
+		// the store keeps its own copy
+		loaded.Captures["black"] += 10
+		loaded.Board.At(0, 0).Color = "white"
+		if again, _ := store.Load(g.ID); summary(again) != summary(g) {
+			t.Errorf("%s: changing a loaded game changed the stored one", name)
+		}
+	}
+
+	// saving replaces the stored game
+	g := games["new"]
+	g.Play(game.Point{X: 4, Y: 4, Color: "black"})
+	if err := store.Save(g); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ := store.Load(g.ID); summary(loaded) != summary(g) {
+		t.Errorf("got %s after saving, want %s", summary(loaded), summary(g))
+	}
+
+	inProgress, err := store.LoadInProgress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 0
+	for _, g := range games {
+		if !g.Ended {
+			want++
+		}
+	}
+	if len(inProgress) != want {
+		t.Errorf("got %d games in progress, want %d", len(inProgress), want)
+	}
+	for i, g := range inProgress {
+		if g.Ended {
+			t.Errorf("game %d has ended", g.ID)
+		}
+		if i > 0 && g.ID <= inProgress[i-1].ID {
+			t.Errorf("games in progress out of order: %d after %d", g.ID, inProgress[i-1].ID)
+		}
+	}
+
+	if _, err := store.Load(1000); err != ErrNotFound {
+		t.Errorf("loading a missing game: got %v, want ErrNotFound", err)
+	}
+	if err := store.Save(game.Game{ID: 1000}); err != ErrNotFound {
+		t.Errorf("saving a missing game: got %v, want ErrNotFound", err)
+	}
+}
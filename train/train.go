@@ -0,0 +1,139 @@
+// Package train fits a player.Network to a self-play dataset (see package selfplay).
+//
+// Every record becomes one example: the policy learns the move chosen in the
+// position and the value learns whether the player to move went on to win.
+// Records from other board sizes are skipped.
+package train
+
+import (
+	"fmt"
+	"io"
+
+	"go-api/game"
+	"go-api/player"
+	"go-api/selfplay"
+)
+
+type Options struct {
+	Size   int
+	Hidden []int   // sizes of the hidden layers of a new network
+	Epochs int     // passes over the dataset
+	Rate   float64 // learning rate
+	From   string  // weights file to carry on training from ("" for a new network)
+}
+
+var DefaultOptions = Options{
+	Size:   9,
+	Hidden: []int{64},
+	Epochs: 10,
+	Rate:   0.01,
+}
+
+// the training example for a record
+func example(r selfplay.Record) (player.TrainingExample, error) {
+	g, err := r.Position()
+	if err != nil {
+		return player.TrainingExample{}, err
+	}
+	result := 0.5
+	if r.Winner == r.ToMove {
+		result = 1
+	} else if r.Winner != "" {
+		result = 0
+	}
+	return player.TrainingExample{
+		Position: g,
+		Move:     game.Point{X: r.X, Y: r.Y},
+		Result:   result,
+	}, nil
+}
+
+// Run trains a network on the records, reporting progress to log (if not nil)
+func Run(records []selfplay.Record, opts Options, log io.Writer) (*player.Network, error) {
+	if opts.Epochs < 1 {
+		return nil, fmt.Errorf("number of epochs must be at least 1")
+	}
+	var n *player.Network
+	if opts.From != "" {
+		var err error
+		if n, err = player.LoadNetwork(opts.From); err != nil {
+			return nil, err
+		}
+		if n.Size() != opts.Size {
+			return nil, fmt.Errorf("%s is for %dx%d boards, not %dx%d", opts.From, n.Size(), n.Size(), opts.Size, opts.Size)
+		}
+	} else {
+		n = player.NewNetwork(opts.Size, opts.Hidden...)
+	}
+
+	examples := []player.TrainingExample{}
+	for i, r := range records {
+		if r.Size != opts.Size {
+			continue
+		}
+		e, err := example(r)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %v", i, err)
+		}
+		examples = append(examples, e)
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("no positions on a %dx%d board to train on", opts.Size, opts.Size)
+	}
+	if log != nil {
+		fmt.Fprintf(log, "training on %d positions for %d epochs\n", len(examples), opts.Epochs)
+	}
+	if err := n.Train(examples, opts.Epochs, opts.Rate); err != nil {
+		return nil, err
+	}
+	if log != nil {
+		fmt.Fprintf(log, "value loss: %.4f, policy accuracy: %.3f\n", valueLoss(n, examples), policyAccuracy(n, examples))
+	}
+	return n, nil
+}
+
+// mean squared error of the value network's predictions
+func valueLoss(n *player.Network, examples []player.TrainingExample) float64 {
+	sum := 0.0
+	for _, e := range examples {
+		_, value := n.Predict(e.Position)
+		sum += (value - e.Result) * (value - e.Result)
+	}
+	return sum / float64(len(examples))
+}
+
+// share of the positions where the policy's favourite move is the one played
+func policyAccuracy(n *player.Network, examples []player.TrainingExample) float64 {
+	hits := 0
+	for _, e := range examples {
+		policy, _ := n.Predict(e.Position)
+		best := 0
+		for i, p := range policy {
+			if p > policy[best] {
+				best = i
+			}
+		}
+		played := len(policy) - 1 // a pass
+		if e.Move.X >= 0 {
+			played = e.Move.Y*n.Size() + e.Move.X
+		}
+		if best == played {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(examples))
+}
+
+// RunToFile trains a network on the dataset in the named file (see selfplay.ReadFile)
+// and saves it to out, in the format read by player.LoadNetwork
+func RunToFile(dataset string, opts Options, out string, log io.Writer) error {
+	records, err := selfplay.ReadFile(dataset)
+	if err != nil {
+		return err
+	}
+	n, err := Run(records, opts, log)
+	if err != nil {
+		return err
+	}
+	return n.Save(out)
+}
@@ -0,0 +1,202 @@
+// Package tune improves the static evaluator's weights by playing the engine
+// against itself, using simultaneous perturbation stochastic approximation (SPSA).
+//
+// Each iteration perturbs every weight of the current config at once, by +c or -c
+// chosen at random, and plays the two resulting configs against each other.
+// The difference in their results estimates which way each weight should move,
+// and the config takes a step that way. The step and perturbation sizes shrink
+// as the iterations go on, so the weights settle down.
+package tune
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+
+	"go-api/game"
+	"go-api/player"
+	"go-api/selfplay"
+)
+
+type Options struct {
+	Iterations int
+	Games      int // games per iteration, half with each config as black
+	Size       int
+	Komi       float64
+	Seed       int64
+	Complexity int     // search complexity of both players (see player.EvalConfig), kept low for speed
+	Step       float64 // size of the first step taken (a in the SPSA literature)
+	Perturb    float64 // size of the first perturbation (c in the SPSA literature)
+	Matches    int     // games played between the tuned and starting configs at the end (0 for none)
+}
+
+var DefaultOptions = Options{
+	Iterations: 100,
+	Games:      4,
+	Size:       5,
+	Komi:       0.5,
+	Seed:       1,
+	Complexity: 1e4,
+	Step:       0.02,
+	Perturb:    0.1,
+	Matches:    20,
+}
+
+// the weights being tuned (the integer settings are left alone)
+func weights(c *player.EvalConfig) []*float64 {
+	return []*float64{
+		&c.EyeWeight,
+		&c.LibertyWeight,
+		&c.AreaWeight,
+		&c.SizeWeight,
+		&c.CaptureWeight,
+		&c.KoWeight,
+		&c.DensityWeight,
+		&c.ConnDepthWeight,
+		&c.GroupAvgWeight,
+	}
+}
+
+// play a game between two configs, returning 1 if black wins, -1 if white wins and 0 for a draw
+func playGame(black, white player.EvalConfig, opts Options) (float64, error) {
+	komi := opts.Komi
+	g, err := game.NewGame(game.Settings{Size: opts.Size, Komi: &komi})
+	if err != nil {
+		return 0, err
+	}
+	configs := map[string]player.EvalConfig{"black": black, "white": white}
+	// parallel searches would make runs irreproducible
+	search := player.SearchConfig{TableSize: player.DefaultSearchConfig.TableSize, Workers: 1}
+	err = selfplay.PlayGame(&g, func(g game.Game) (game.Point, error) {
+		return player.SearchContext(context.Background(), g, g.Turn, configs[g.Turn], search).Move, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	switch g.Winner {
+	case "black":
+		return 1, nil
+	case "white":
+		return -1, nil
+	}
+	return 0, nil
+}
+
+// play a match between two configs, alternating colors
+// returns a's score from -1 (lost every game) to 1 (won every game)
+func match(a, b player.EvalConfig, games int, opts Options) (float64, error) {
+	total := 0.0
+	for i := 0; i < games; i++ {
+		if i%2 == 0 {
+			r, err := playGame(a, b, opts)
+			if err != nil {
+				return 0, err
+			}
+			total += r
+		} else {
+			r, err := playGame(b, a, opts)
+			if err != nil {
+				return 0, err
+			}
+			total -= r
+		}
+	}
+	return total / float64(games), nil
+}
+
+// Run tunes the weights of start, reporting progress to log (if not nil)
+// if the tuned config loses its final match against start, start is returned instead,
+// and tuned reports which of the two was returned
+func Run(start player.EvalConfig, opts Options, log io.Writer) (config player.EvalConfig, tuned bool, err error) {
+	if opts.Iterations < 1 || opts.Games < 1 {
+		return start, false, fmt.Errorf("iterations and games must be at least 1")
+	}
+	if opts.Complexity < 1 {
+		return start, false, fmt.Errorf("complexity must be positive")
+	}
+	if log == nil {
+		log = ioutil.Discard
+	}
+	player.Seed(opts.Seed)
+	r := rand.New(rand.NewSource(opts.Seed))
+
+	original := start
+	start.Complexity = opts.Complexity
+	config = start
+	theta := weights(&config)
+	delta := make([]float64, len(theta))
+	// the usual SPSA gain sequences, scaled so the first step and perturbation are
+	// opts.Step and opts.Perturb, with the step's decay delayed by a tenth of the run
+	stability := float64(opts.Iterations) / 10
+	for k := 0; k < opts.Iterations; k++ {
+		a := opts.Step / math.Pow(float64(k+1)+stability, 0.602) * math.Pow(stability+1, 0.602)
+		c := opts.Perturb / math.Pow(float64(k+1), 0.101)
+
+		plus, minus := config, config
+		plusWeights, minusWeights := weights(&plus), weights(&minus)
+		for i := range theta {
+			delta[i] = float64(2*r.Intn(2) - 1)
+			*plusWeights[i] = math.Max(0, *theta[i]+c*delta[i])
+			*minusWeights[i] = math.Max(0, *theta[i]-c*delta[i])
+		}
+		score, err := match(plus, minus, opts.Games, opts)
+		if err != nil {
+			return config, false, err
+		}
+		// the match score stands in for the difference between the two configs' results
+		for i := range theta {
+			*theta[i] = math.Max(0, *theta[i]+a*score/(2*c*delta[i]))
+		}
+		fmt.Fprintf(log, "iteration %d: %+.2f %v\n", k+1, score, weightValues(config))
+	}
+
+	if opts.Matches > 0 {
+		score, err := match(config, start, opts.Matches, opts)
+		if err != nil {
+			return config, false, err
+		}
+		fmt.Fprintf(log, "tuned config scored %+.2f against the starting config\n", score)
+		if score < 0 {
+			return original, false, nil
+		}
+	}
+	config.Complexity = original.Complexity
+	return config, true, nil
+}
+
+func weightValues(c player.EvalConfig) []float64 {
+	values := []float64{}
+	for _, w := range weights(&c) {
+		values = append(values, math.Round(*w*1000)/1000)
+	}
+	return values
+}
+
+// RunToFile is Run writing the tuned config to the named file under the given name,
+// in the format read by player.LoadPresets
+// (the starting config is written instead if the tuned one lost to it, as reported to log)
+func RunToFile(start player.EvalConfig, opts Options, path string, name string, log io.Writer) error {
+	config, tuned, err := Run(start, opts, log)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(map[string]player.EvalConfig{name: config}, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if log != nil {
+		if tuned {
+			fmt.Fprintf(log, "wrote the tuned config to %s\n", path)
+		} else {
+			fmt.Fprintf(log, "the tuned config lost to the starting config, so wrote the starting config to %s\n", path)
+		}
+	}
+	return nil
+}